@@ -0,0 +1,232 @@
+package analyzer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"shardanalyzer/collector"
+	"shardanalyzer/config"
+	"shardanalyzer/models"
+	"shardanalyzer/reports"
+	"shardanalyzer/secrets"
+	"sync"
+	"time"
+)
+
+// Request is the analysis of a cluster, from the cat APIs it carries or collected from its domain.
+// The JSON names are the ones of the Lambda requests.
+type Request struct {
+	ClusterName         string        `json:"clustername"`
+	ClientName          string        `json:"clientname"` // customer the cluster belongs to, the title of the response
+	AvailabilityZones   int           `json:"availabilityzones"`
+	Search              bool          `json:"search"` // keeps the replica count instead of recommending 1
+	TargetSize          int           `json:"targetsize"`
+	TargetDocs          int64         `json:"targetdocs"`
+	DomainEndpoint      string        `json:"domainendpoint"`
+	Username            string        `json:"username"`
+	Password            string        `json:"password"`
+	CredentialRef       string        `json:"credentialref"` // Secrets Manager ARN or SSM parameter holding the username and password
+	Region              string        `json:"region"`
+	RoleArn             string        `json:"rolearn"`
+	CACert              string        `json:"cacert"`
+	InsecureSkipVerify  bool          `json:"insecureskipverify"`
+	ProxyURL            string        `json:"proxyurl"`
+	CatShards           string        `json:"rawInput"`
+	CatAliases          string        `json:"rawAliases"`
+	CatSegments         string        `json:"rawSegments"`
+	CatIndices          string        `json:"rawIndices"`
+	CatNodes            string        `json:"rawNodes"`
+	ShardsPerPattern    bool          `json:"shardsperpattern"`
+	CleanupMaxDocs      int64         `json:"cleanupmaxdocs"`
+	CleanupMaxSizeMB    int           `json:"cleanupmaxsizemb"`
+	DeductCleanup       bool          `json:"deductcleanup"`
+	SystemIndices       string        `json:"systemindices"`
+	HiddenIndices       string        `json:"hiddenindices"`
+	DataStreamIndices   string        `json:"datastreamindices"`
+	SystemIndexPatterns []string      `json:"systemindexpatterns"`
+	SkewFactor          float64       `json:"skewfactor"`
+	LargeShardGB        int           `json:"largeshardgb"`
+	SmallShardMB        int           `json:"smallshardmb"`
+	MaxShardDocs        int64         `json:"maxsharddocs"`
+	DocLimitFraction    float64       `json:"doclimitfraction"`
+	Theme               reports.Theme `json:"theme"`  // branding of the PDF report over the theme of the service
+	Locale              string        `json:"locale"` // language of the report like "de"
+}
+
+func (r Request) getArgs() config.ShardRecommendationRequest {
+	return config.ShardRecommendationRequest{
+		CatShards:             r.CatShards,
+		CatAliases:            r.CatAliases,
+		CatSegments:           r.CatSegments,
+		CatIndices:            r.CatIndices,
+		CatNodes:              r.CatNodes,
+		ShardsPerPattern:      r.ShardsPerPattern,
+		TargetShardSizeGB:     r.TargetSize,
+		TargetDocsPerShard:    r.TargetDocs,
+		NumberOfAzs:           r.AvailabilityZones,
+		IsSearchWorkload:      r.Search,
+		ClusterName:           r.ClusterName,
+		ClientName:            r.ClientName,
+		CleanupMaxDocs:        r.CleanupMaxDocs,
+		CleanupMaxSizeMB:      r.CleanupMaxSizeMB,
+		DeductCleanup:         r.DeductCleanup,
+		SystemIndexPolicy:     r.SystemIndices,
+		HiddenIndexPolicy:     r.HiddenIndices,
+		DataStreamIndexPolicy: r.DataStreamIndices,
+		SystemIndexPatterns:   r.SystemIndexPatterns,
+		SkewFactor:            r.SkewFactor,
+		LargeShardGB:          r.LargeShardGB,
+		SmallShardMB:          r.SmallShardMB,
+		MaxShardDocs:          r.MaxShardDocs,
+		DocLimitFraction:      r.DocLimitFraction,
+	}
+}
+
+// Result answers a request with the JSON response, or with the report of another content type.
+type Result struct {
+	Response    Response
+	ContentType string
+	Body        []byte
+}
+
+// Headers are the Content-Type of the body and the Content-Disposition of downloads.
+func (r Result) Headers() map[string]string {
+	headers := map[string]string{"Content-Type": r.ContentType}
+	if r.ContentType != reports.ContentTypeJSON && !reports.IsBinary(r.ContentType) {
+		headers["Content-Type"] += "; charset=utf-8"
+	}
+	if fileName, ok := reports.GetFileName(r.ContentType); ok {
+		headers["Content-Disposition"] = `attachment; filename="` + fileName + `"`
+	}
+	return headers
+}
+
+// Service runs the analyses of the Lambda, the server and the command line.
+type Service struct {
+	ThemeFile   string           // JSON or YAML theme the themes of the requests apply over
+	Reserve     time.Duration    // kept back from the deadline of the context to build the report after collecting
+	Credentials secrets.Resolver // resolves credentialref, the default AWS resolver is created on first use when nil
+	Now         func() time.Time // clock of the reports and the doc limit projections, time.Now when nil
+	mu          sync.Mutex
+}
+
+// Analyze answers the request in the content type, errors are *Error with the status to answer with.
+func (s *Service) Analyze(ctx context.Context, request Request, contentType string) (Result, error) {
+	request = request.WithDefaults()
+	if err := request.Validate(); err != nil {
+		return Result{}, err
+	}
+	if err := s.resolveCredentials(ctx, &request); err != nil {
+		return Result{}, err
+	}
+	theme, err := s.getTheme(request.Theme)
+	if err != nil {
+		return Result{}, err
+	}
+	cluster, err := s.getCluster(ctx, request)
+	if err != nil {
+		return Result{}, err
+	}
+	cluster.Now = s.Now
+	recommendation := cluster.PrepareRecommendation()
+	reports.MergeSingleIndexPatterns(&recommendation)
+
+	result := Result{Response: newResponse(recommendation, cluster.Nodes), ContentType: contentType}
+	renderer, ok := reports.GetRenderer(contentType, reports.Options{Theme: theme, Locale: reports.MatchLocale(request.Locale), Now: s.Now})
+	if !ok {
+		result.ContentType = reports.ContentTypeJSON
+		if result.Body, err = json.Marshal(result.Response); err != nil {
+			return Result{}, &Error{Status: http.StatusInternalServerError, Message: "error occured in json.Marshal of the final response", Err: err}
+		}
+		return result, nil
+	}
+	var buf bytes.Buffer
+	if err = renderer.Render(&buf, recommendation, cluster.Nodes); err != nil {
+		return Result{}, &Error{Status: http.StatusInternalServerError, Message: "error occured in rendering the report", Err: err}
+	}
+	result.Body = buf.Bytes()
+	return result, nil
+}
+
+// resolveCredentials replaces the credential reference of the request by the username and password it points to
+func (s *Service) resolveCredentials(ctx context.Context, request *Request) error {
+	if request.CredentialRef == "" {
+		return nil
+	}
+	resolver, err := s.getResolver(ctx)
+	if err != nil {
+		return &Error{Status: http.StatusBadRequest, Err: err}
+	}
+	creds, err := resolver.Resolve(ctx, request.CredentialRef)
+	if err != nil {
+		return &Error{Status: http.StatusBadRequest, Message: "error occured in resolving credentialref", Err: err}
+	}
+	request.Username, request.Password = creds.Username, creds.Password
+	return nil
+}
+
+func (s *Service) getResolver(ctx context.Context) (secrets.Resolver, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Credentials == nil {
+		resolver, err := secrets.NewDefaultAWSResolver(ctx)
+		if err != nil {
+			return nil, err
+		}
+		s.Credentials = resolver
+	}
+	return s.Credentials, nil
+}
+
+// getTheme applies the theme of the request over the one of the service
+func (s *Service) getTheme(theme reports.Theme) (reports.Theme, error) {
+	base, err := reports.LoadTheme(s.ThemeFile)
+	if err != nil {
+		return base, &Error{Status: http.StatusInternalServerError, Message: "error occured in loading the report theme", Err: err}
+	}
+	theme = base.Merge(theme)
+	if err = theme.Validate(); err != nil {
+		return theme, invalid("theme." + err.Error())
+	}
+	return theme, nil
+}
+
+// getCluster parses the cat APIs of the request or streams them from its domain
+func (s *Service) getCluster(ctx context.Context, request Request) (*models.Cluster, error) {
+	args := request.getArgs()
+	if request.DomainEndpoint == "" {
+		cluster, err := args.ParseStats()
+		if err != nil {
+			return nil, &Error{Status: http.StatusBadRequest, Message: "error occured in parsing stats", Err: err}
+		}
+		return cluster, nil
+	}
+	ctx, cancel := collector.WithReservedTime(ctx, s.Reserve) // stop collecting before the caller times out
+	defer cancel()
+	domain, err := collector.New(ctx, collector.Options{
+		Endpoint:           request.DomainEndpoint,
+		Username:           request.Username, // basic auth when set, SigV4 signed requests otherwise
+		Password:           request.Password,
+		Region:             request.Region,
+		RoleArn:            request.RoleArn,
+		CACert:             request.CACert,
+		InsecureSkipVerify: request.InsecureSkipVerify,
+		ProxyURL:           request.ProxyURL,
+	})
+	if err != nil {
+		return nil, &Error{Status: collector.HTTPStatus(err), Err: err}
+	}
+	cluster, err := args.CollectStats(ctx, domain) // responses are parsed while they are read
+	if err != nil {
+		// the message holds the response body of failed requests
+		status := http.StatusBadRequest
+		var collectorError *collector.Error
+		if errors.As(err, &collectorError) {
+			status = collectorError.HTTPStatus()
+		}
+		return nil, &Error{Status: status, Message: "error occured in collecting stats", Err: err}
+	}
+	return cluster, nil
+}
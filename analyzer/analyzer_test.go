@@ -0,0 +1,92 @@
+package analyzer
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"shardanalyzer/reports"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// a daily pattern with too many shards, an index with a shard over 50 GB and an empty index
+var catShards = readTestdata("cat_shards_mixed.txt")
+
+// readTestdata reads a fixture of the testdata directory the packages share
+func readTestdata(name string) string {
+	data, err := os.ReadFile(filepath.Join("..", "testdata", name))
+	if err != nil {
+		panic(err)
+	}
+	return string(data)
+}
+
+// getTestRequest is the request of testdata/request.json with the shards of catShards
+func getTestRequest() Request {
+	var request Request
+	if err := json.Unmarshal([]byte(readTestdata("request.json")), &request); err != nil {
+		panic(err)
+	}
+	request.CatShards = catShards
+	return request
+}
+
+func Test_analyze(t *testing.T) {
+	result, err := (&Service{}).Analyze(context.Background(), getTestRequest(), reports.ContentTypeJSON)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"Content-Type": reports.ContentTypeJSON}, result.Headers())
+
+	response := result.Response
+	assert.Equal(t, "prod", response.ClusterName)
+	assert.Equal(t, "acme", response.Title)
+	assert.True(t, response.NeedAdjustment)
+	if assert.Len(t, response.LargeIndices, 1) {
+		assert.Equal(t, "orders", response.LargeIndices[0].Name)
+	}
+	assert.Equal(t, "node-1", response.NodeStats[0].NodeName)
+	var body Response
+	assert.NoError(t, json.Unmarshal(result.Body, &body))
+	assert.Equal(t, response.ClusterName, body.ClusterName)
+}
+
+func Test_analyzeReport(t *testing.T) {
+	result, err := (&Service{}).Analyze(context.Background(), getTestRequest(), reports.ContentTypeText)
+	assert.NoError(t, err)
+	assert.Equal(t, "text/plain; charset=utf-8", result.Headers()["Content-Type"])
+	assert.Contains(t, string(result.Body), "orders")
+
+	result, err = (&Service{}).Analyze(context.Background(), getTestRequest(), reports.ContentTypeCSV)
+	assert.NoError(t, err)
+	assert.Equal(t, `attachment; filename="shard-analyzer-indices.csv"`, result.Headers()["Content-Disposition"])
+}
+
+func Test_analyzeErrors(t *testing.T) {
+	service := &Service{}
+	for name, test := range map[string]struct {
+		update  func(*Request)
+		status  int
+		message string
+	}{
+		"no input":    {func(r *Request) { r.CatShards = "" }, http.StatusBadRequest, "rawInput or domainendpoint is required"},
+		"credentials": {func(r *Request) { r.CredentialRef, r.Username = "arn:aws:secretsmanager:secret", "admin" }, http.StatusBadRequest, "credentialref can't be combined with username and password"},
+		"theme":       {func(r *Request) { r.Theme.PageSize = "B5" }, http.StatusBadRequest, "theme pagesize must be one of A3, A4, A5, Letter or Legal"},
+		"bad policy":  {func(r *Request) { r.SystemIndices = "drop" }, http.StatusBadRequest, "systemindices must be one of exclude, report, analyze"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			request := getTestRequest()
+			test.update(&request)
+			_, err := service.Analyze(context.Background(), request, reports.ContentTypeJSON)
+			assert.ErrorContains(t, err, test.message)
+			assert.Equal(t, test.status, HTTPStatus(err))
+		})
+	}
+
+	// a broken theme of the service is not the fault of the request
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "theme.json"), []byte("{"), 0644))
+	_, err := (&Service{ThemeFile: filepath.Join(dir, "theme.json")}).Analyze(context.Background(), getTestRequest(), reports.ContentTypeJSON)
+	assert.Equal(t, http.StatusInternalServerError, HTTPStatus(err))
+}
@@ -0,0 +1,76 @@
+package analyzer
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// Error is a failed analysis with the status the transports answer with.
+type Error struct {
+	Status  int
+	Message string       // what failed, the message of Err follows it
+	Fields  []FieldError // invalid fields of the request
+	Err     error
+}
+
+func (e *Error) Error() string {
+	message := e.Message
+	switch {
+	case e.Err == nil:
+	case message == "":
+		message = e.Err.Error()
+	default:
+		message += ": " + e.Err.Error()
+	}
+	if len(e.Fields) > 0 {
+		fields := make([]string, len(e.Fields))
+		for i, field := range e.Fields {
+			fields[i] = field.Field + " " + field.Message
+		}
+		message += ": " + strings.Join(fields, ", ")
+	}
+	return message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// HTTPStatus is the status of errors of this package, anything else is an internal error.
+func HTTPStatus(err error) int {
+	var analyzerError *Error
+	if errors.As(err, &analyzerError) {
+		return analyzerError.Status
+	}
+	return http.StatusInternalServerError
+}
+
+// ErrorResponse is the JSON body of all error responses.
+//
+//	{"error": {"status": 400, "message": "invalid request", "fields": [{"field": "clustername", "message": "is required"}]}}
+type ErrorResponse struct {
+	Error ErrorDetails `json:"error"`
+}
+
+type ErrorDetails struct {
+	Status  int          `json:"status"`
+	Message string       `json:"message"`
+	Fields  []FieldError `json:"fields,omitempty"`
+}
+
+// NewErrorResponse answers the error, the fields of invalid requests are listed apart from the message.
+func NewErrorResponse(err error) ErrorResponse {
+	details := ErrorDetails{Status: HTTPStatus(err), Message: err.Error()}
+	var analyzerError *Error
+	if errors.As(err, &analyzerError) && len(analyzerError.Fields) > 0 {
+		withoutFields := *analyzerError
+		withoutFields.Fields = nil
+		details.Message, details.Fields = withoutFields.Error(), analyzerError.Fields
+	}
+	return ErrorResponse{Error: details}
+}
+
+func invalid(message string) *Error {
+	return &Error{Status: http.StatusBadRequest, Message: message}
+}
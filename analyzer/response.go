@@ -0,0 +1,86 @@
+package analyzer
+
+import (
+	"shardanalyzer/models"
+	"sort"
+)
+
+// Response is the JSON answer to a request, the same for every transport.
+type Response struct {
+	Title                            string                              `json:"title"` // the client name of the request
+	ClusterName                      string                              `json:"cluster_name"`
+	NumberOfAZs                      int                                 `json:"number_of_azs"`
+	NumberOfDataNodes                int                                 `json:"number_of_data_nodes"`
+	TotalPrimarySize                 int64                               `json:"total_primary_size"`
+	TotalReplicaSize                 int64                               `json:"total_replica_size"`
+	TotalShards                      int                                 `json:"total_shards"`
+	PotentialShards                  int                                 `json:"potential_shards"`
+	TotalIndices                     int                                 `json:"total_indices"`        // Index Count is from summing length of each Indices array within the IndexPatternRecommendation that is within the IndexPatternRecommendationRollup array
+	TotalIndexPatterns               int                                 `json:"total_index_patterns"` // number of IndexPatternRecommendation structs that have Pattern!=No Patterns
+	RecommendedShardSizeInGb         int                                 `json:"recommended_shard_size_in_gb"`
+	TargetDocsPerShard               int64                               `json:"target_docs_per_shard,omitempty"`
+	NeedAdjustment                   bool                                `json:"need_adjustment"`
+	NodeStats                        []models.NodeStats                  `json:"node_stats"`                          // sorted by name
+	LargeIndices                     []models.IndexRecommendation        `json:"large_indices"`                       // Array of Indices with shards over the large shard threshold (50g by default)
+	IndexPatternRecommendationRollup []models.IndexPatternRecommendation `json:"index_pattern_recommendation_rollup"` // Array of IndexPatternRecommendation structs
+	EmptyIndices                     []string                            `json:"empty_indices,omitempty"`             // Array of strings listing the empty indices
+	CleanupIndices                   []models.CleanupIndex               `json:"cleanup_indices,omitempty"`           // Empty, near-empty and closed indices
+	CleanupShards                    int                                 `json:"cleanup_shards"`
+	CleanupCommands                  []string                            `json:"cleanup_commands,omitempty"` // Batched DELETE commands for the cleanup indices
+	SystemIndexCount                 int                                 `json:"system_index_count"`
+	HiddenIndexCount                 int                                 `json:"hidden_index_count"`
+	DataStreamIndexCount             int                                 `json:"data_stream_index_count"`
+	ReportedIndices                  []models.ClassifiedIndex            `json:"reported_indices,omitempty"` // System and hidden indices listed without analysis
+	DataStreams                      []models.DataStream                 `json:"data_streams,omitempty"`
+	RolloverAliases                  []models.RolloverAlias              `json:"rollover_aliases,omitempty"`
+	SegmentRecommendations           []models.IndexSegmentRecommendation `json:"segment_recommendations,omitempty"`
+	ForceMergeCommands               []string                            `json:"force_merge_commands,omitempty"`
+	BloatedIndices                   []models.IndexBloat                 `json:"bloated_indices,omitempty"`
+	SkewedIndices                    []models.ShardSkew                  `json:"skewed_indices,omitempty"` // Indices with custom routing skew
+	Findings                         []models.Finding                    `json:"findings"`                 // Everything flagged by the analysis, most severe first
+}
+
+func newResponse(recommendation models.Recommendation, nodes map[string]*models.NodeStats) Response {
+	var nodeStats []models.NodeStats
+	for _, ns := range nodes {
+		nodeStats = append(nodeStats, *ns)
+	}
+	sort.Slice(nodeStats, func(i, j int) bool {
+		return nodeStats[i].NodeName < nodeStats[j].NodeName
+	})
+	_, largeIndices := recommendation.GetIndicesWithLargerShards(recommendation.Thresholds.LargeShardGB)
+
+	return Response{
+		Title:                            recommendation.Title,
+		ClusterName:                      recommendation.ClusterName,
+		NumberOfAZs:                      recommendation.NumberOfAZs,
+		NumberOfDataNodes:                recommendation.NumberOfDataNodes,
+		TotalPrimarySize:                 recommendation.TotalPrimarySize,
+		TotalReplicaSize:                 recommendation.TotalReplicaSize,
+		TotalShards:                      recommendation.TotalShards,
+		PotentialShards:                  recommendation.PotentialShards,
+		TotalIndices:                     recommendation.GetIndexCount(),
+		TotalIndexPatterns:               recommendation.GetTotalIndexPatterns(),
+		RecommendedShardSizeInGb:         recommendation.RecommendedShardSizeInGb,
+		TargetDocsPerShard:               recommendation.TargetDocsPerShard,
+		NeedAdjustment:                   recommendation.NeedsShardAdjustment(),
+		NodeStats:                        nodeStats,
+		LargeIndices:                     largeIndices,
+		IndexPatternRecommendationRollup: recommendation.IndexPatternRecommendationRollup,
+		EmptyIndices:                     recommendation.EmptyIndices,
+		CleanupIndices:                   recommendation.CleanupIndices,
+		CleanupShards:                    recommendation.CleanupShards,
+		CleanupCommands:                  recommendation.GetCleanupCommands(models.DefaultCleanupBatchSize),
+		SystemIndexCount:                 recommendation.SystemIndexCount,
+		HiddenIndexCount:                 recommendation.HiddenIndexCount,
+		DataStreamIndexCount:             recommendation.DataStreamIndexCount,
+		ReportedIndices:                  recommendation.ReportedIndices,
+		DataStreams:                      recommendation.DataStreams,
+		RolloverAliases:                  recommendation.RolloverAliases,
+		SegmentRecommendations:           recommendation.SegmentRecommendations,
+		ForceMergeCommands:               recommendation.GetForceMergeCommands(),
+		BloatedIndices:                   recommendation.BloatedIndices,
+		SkewedIndices:                    recommendation.SkewedIndices,
+		Findings:                         recommendation.Findings,
+	}
+}
@@ -0,0 +1,150 @@
+package analyzer
+
+import (
+	"fmt"
+	"shardanalyzer/models"
+	"shardanalyzer/reports"
+	"strings"
+)
+
+// target shard sizes of the workloads for requests without one, the middle of the ranges the
+// README recommends
+const (
+	DefaultLogsTargetSizeGB   = 40
+	DefaultSearchTargetSizeGB = 20
+)
+
+// FieldError is an invalid field of a request, named like in the JSON of the Lambda.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// rule checks one field of a request, check returns what is wrong with it or ""
+type rule struct {
+	field string
+	check func(Request) string
+}
+
+var indexPolicies = []string{string(models.PolicyExclude), string(models.PolicyReport), string(models.PolicyAnalyze)}
+
+// requestRules are checked after the defaults are applied, every failed one is reported
+var requestRules = []rule{
+	required("clustername", func(r Request) string { return r.ClusterName }),
+	oneRequired("rawInput", "domainendpoint", func(r Request) (bool, bool) { return r.CatShards != "", r.DomainEndpoint != "" }),
+	exclusive("rawInput", "domainendpoint", func(r Request) (bool, bool) { return r.CatShards != "", r.DomainEndpoint != "" }),
+	exclusive("credentialref", "username and password", func(r Request) (bool, bool) {
+		return r.CredentialRef != "", r.Username != "" || r.Password != ""
+	}),
+	between("availabilityzones", 1, 3, func(r Request) float64 { return float64(r.AvailabilityZones) }),
+	atLeast("targetsize", 1, func(r Request) float64 { return float64(r.TargetSize) }),
+	atLeast("targetdocs", 0, func(r Request) float64 { return float64(r.TargetDocs) }),
+	atLeast("cleanupmaxdocs", 0, func(r Request) float64 { return float64(r.CleanupMaxDocs) }),
+	atLeast("cleanupmaxsizemb", 0, func(r Request) float64 { return float64(r.CleanupMaxSizeMB) }),
+	oneOf("systemindices", indexPolicies, func(r Request) string { return r.SystemIndices }),
+	oneOf("hiddenindices", indexPolicies, func(r Request) string { return r.HiddenIndices }),
+	oneOf("datastreamindices", indexPolicies, func(r Request) string { return r.DataStreamIndices }),
+	{"skewfactor", func(r Request) string {
+		if r.SkewFactor != 0 && r.SkewFactor <= 1 {
+			return "must be greater than 1, or 0 for the default"
+		}
+		return ""
+	}},
+	atLeast("largeshardgb", 0, func(r Request) float64 { return float64(r.LargeShardGB) }),
+	atLeast("smallshardmb", 0, func(r Request) float64 { return float64(r.SmallShardMB) }),
+	atLeast("maxsharddocs", 0, func(r Request) float64 { return float64(r.MaxShardDocs) }),
+	between("doclimitfraction", 0, 1, func(r Request) float64 { return r.DocLimitFraction }),
+	{"theme", func(r Request) string {
+		if err := reports.DefaultTheme().Merge(r.Theme).Validate(); err != nil {
+			return err.Error()
+		}
+		return ""
+	}},
+}
+
+// WithDefaults returns the request with the target shard size of its workload when it has none.
+func (r Request) WithDefaults() Request {
+	if r.TargetSize == 0 {
+		r.TargetSize = DefaultLogsTargetSizeGB
+		if r.Search {
+			r.TargetSize = DefaultSearchTargetSizeGB
+		}
+	}
+	return r
+}
+
+// Validate returns an *Error with every invalid field of the request, nil when it can be analyzed.
+func (r Request) Validate() error {
+	var fields []FieldError
+	for _, rule := range requestRules {
+		if message := rule.check(r); message != "" {
+			fields = append(fields, FieldError{Field: rule.field, Message: message})
+		}
+	}
+	if len(fields) > 0 {
+		return &Error{Status: 400, Message: "invalid request", Fields: fields}
+	}
+	return nil
+}
+
+func required(field string, value func(Request) string) rule {
+	return rule{field, func(r Request) string {
+		if strings.TrimSpace(value(r)) == "" {
+			return "is required"
+		}
+		return ""
+	}}
+}
+
+func oneRequired(field, other string, set func(Request) (bool, bool)) rule {
+	return rule{field, func(r Request) string {
+		if isSet, otherSet := set(r); !isSet && !otherSet {
+			return "or " + other + " is required"
+		}
+		return ""
+	}}
+}
+
+// exclusive rejects the field when the other one is set too
+func exclusive(field, other string, set func(Request) (bool, bool)) rule {
+	return rule{field, func(r Request) string {
+		if isSet, otherSet := set(r); isSet && otherSet {
+			return "can't be combined with " + other
+		}
+		return ""
+	}}
+}
+
+func between(field string, min, max float64, value func(Request) float64) rule {
+	return rule{field, func(r Request) string {
+		if v := value(r); v < min || v > max {
+			return fmt.Sprintf("must be between %v and %v", min, max)
+		}
+		return ""
+	}}
+}
+
+func atLeast(field string, min float64, value func(Request) float64) rule {
+	return rule{field, func(r Request) string {
+		if value(r) < min {
+			return fmt.Sprintf("must be at least %v", min)
+		}
+		return ""
+	}}
+}
+
+// oneOf accepts the values case insensitively, or no value for the default
+func oneOf(field string, values []string, value func(Request) string) rule {
+	return rule{field, func(r Request) string {
+		v := strings.ToLower(strings.TrimSpace(value(r)))
+		if v == "" {
+			return ""
+		}
+		for _, allowed := range values {
+			if v == allowed {
+				return ""
+			}
+		}
+		return "must be one of " + strings.Join(values, ", ")
+	}}
+}
@@ -0,0 +1,67 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_validate(t *testing.T) {
+	assert.NoError(t, getTestRequest().Validate())
+
+	// every invalid field is listed, in the order of the rules
+	err := Request{
+		CatShards:         catShards,
+		DomainEndpoint:    "https://search.example.com",
+		AvailabilityZones: 4,
+		TargetSize:        -1,
+		TargetDocs:        -1,
+		SkewFactor:        0.5,
+		DocLimitFraction:  1.5,
+		HiddenIndices:     "drop",
+	}.Validate()
+	assert.Equal(t, http.StatusBadRequest, HTTPStatus(err))
+	assert.Equal(t, []FieldError{
+		{"clustername", "is required"},
+		{"rawInput", "can't be combined with domainendpoint"},
+		{"availabilityzones", "must be between 1 and 3"},
+		{"targetsize", "must be at least 1"},
+		{"targetdocs", "must be at least 0"},
+		{"hiddenindices", "must be one of exclude, report, analyze"},
+		{"skewfactor", "must be greater than 1, or 0 for the default"},
+		{"doclimitfraction", "must be between 0 and 1"},
+	}, err.(*Error).Fields)
+
+	for name, update := range map[string]func(*Request){
+		"azs":          func(r *Request) { r.AvailabilityZones = 0 },
+		"cluster name": func(r *Request) { r.ClusterName = " " },
+		"target size":  func(r *Request) { r.TargetSize = 0 }, // a divisor of the sizing, defaults only apply in Analyze
+	} {
+		request := getTestRequest()
+		update(&request)
+		assert.Error(t, request.Validate(), name)
+	}
+}
+
+func Test_withDefaults(t *testing.T) {
+	assert.Equal(t, DefaultLogsTargetSizeGB, Request{}.WithDefaults().TargetSize)
+	assert.Equal(t, DefaultSearchTargetSizeGB, Request{Search: true}.WithDefaults().TargetSize)
+	assert.Equal(t, 30, Request{TargetSize: 30, Search: true}.WithDefaults().TargetSize)
+
+	request := getTestRequest()
+	request.TargetSize = 0
+	assert.NoError(t, request.WithDefaults().Validate())
+}
+
+func Test_errorResponse(t *testing.T) {
+	body, err := json.Marshal(NewErrorResponse(Request{CatShards: catShards, AvailabilityZones: 1}.Validate()))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"error": {"status": 400, "message": "invalid request", "fields": [
+		{"field": "clustername", "message": "is required"}, {"field": "targetsize", "message": "must be at least 1"}]}}`, string(body))
+
+	body, err = json.Marshal(NewErrorResponse(&Error{Status: http.StatusBadGateway, Message: "error occured in collecting stats", Err: assert.AnError}))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"error": {"status": 502, "message": "error occured in collecting stats: `+assert.AnError.Error()+`"}}`, string(body))
+}
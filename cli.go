@@ -0,0 +1,172 @@
+package main
+
+import (
+	"shardanalyzer/analyzer"
+	"shardanalyzer/controller"
+	"shardanalyzer/reports"
+
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/aws/aws-lambda-go/lambda"
+)
+
+const usage = `Usage: shardanalyzer <command> [flags]
+
+Commands:
+  analyze   analyze the output of _cat/shards?v from a file or stdin
+  serve     run the HTTP server with the swagger UI
+  lambda    run as AWS Lambda function behind API Gateway (default)
+
+Run shardanalyzer <command> -h for the flags of a command.
+`
+
+// analyzeCommand answers the analyze command, the tests replace it
+var analyzeCommand = service.Analyze
+
+// runCommand runs the subcommand of the arguments and returns the exit code, without one the
+// binary is the Lambda function the runtime starts
+func runCommand(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		lambda.Start(Handler)
+		return 0
+	}
+	switch args[0] {
+	case "analyze":
+		return runAnalyze(args[1:], stdin, stdout, stderr)
+	case "serve":
+		return runServe(args[1:], stderr)
+	case "lambda":
+		lambda.Start(Handler)
+		return 0
+	case "help", "-h", "-help", "--help":
+		fmt.Fprint(stdout, usage)
+		return 0
+	}
+	fmt.Fprintf(stderr, "unknown command %q\n\n%s", args[0], usage)
+	return 2
+}
+
+// runAnalyze analyzes the cat/shards input with the options of the flags and writes the report
+func runAnalyze(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("analyze", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "Usage: shardanalyzer analyze [flags] [file]\n\nReads _cat/shards?v from the file, or from stdin when it is missing or -.\n\nFlags:")
+		flags.PrintDefaults()
+	}
+	var request analyzer.Request
+	flags.IntVar(&request.TargetSize, "target-size", 0, "target shard size in GB, 40 for logs and 20 for search when 0")
+	flags.Int64Var(&request.TargetDocs, "target-docs", 0, "target docs per shard, shards are sized for whichever of size and docs needs more of them")
+	flags.IntVar(&request.AvailabilityZones, "azs", 3, "number of availability zones of the cluster")
+	workload := flags.String("workload", "logs", "logs to recommend 1 replica, search to keep the replica count")
+	flags.StringVar(&request.ClusterName, "cluster-name", "", "name of the cluster (required)")
+	flags.StringVar(&request.ClientName, "client-name", "", "name of the customer")
+	flags.StringVar(&request.Locale, "locale", "", "language of the report, en or de")
+	format := flags.String("format", "table", "table, json, pdf, markdown, html, csv or xlsx")
+	output := flags.String("output", "", "file to write the report to instead of stdout")
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+	switch *workload {
+	case "logs":
+	case "search":
+		request.Search = true
+	default:
+		fmt.Fprintf(stderr, "-workload must be logs or search, not %q\n", *workload)
+		return 2
+	}
+	contentType, ok := reports.GetFormatContentType(*format)
+	if !ok {
+		fmt.Fprintf(stderr, "-format must be table, json, pdf, markdown, html, csv or xlsx, not %q\n", *format)
+		return 2
+	}
+	if flags.NArg() > 1 {
+		flags.Usage()
+		return 2
+	}
+
+	input := stdin
+	if file := flags.Arg(0); file != "" && file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		defer f.Close()
+		input = f
+	}
+	catShards, err := io.ReadAll(input)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	request.CatShards = string(catShards)
+
+	result, err := analyzeCommand(context.Background(), request, contentType)
+	if err != nil {
+		return printError(stderr, err)
+	}
+	if *output != "" {
+		err = os.WriteFile(*output, result.Body, 0644)
+	} else {
+		_, err = stdout.Write(result.Body)
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// flags of the request fields
+var flagNames = map[string]string{
+	"clustername":       "-cluster-name",
+	"clientname":        "-client-name",
+	"targetsize":        "-target-size",
+	"targetdocs":        "-target-docs",
+	"availabilityzones": "-azs",
+	"rawInput":          "the input",
+}
+
+// printError prints the error with a line per invalid flag and returns the exit code, 2 for invalid flags
+func printError(stderr io.Writer, err error) int {
+	response := analyzer.NewErrorResponse(err)
+	fmt.Fprintln(stderr, "ERROR: "+response.Error.Message)
+	for _, field := range response.Error.Fields {
+		name, ok := flagNames[field.Field]
+		if !ok {
+			name = field.Field
+		}
+		fmt.Fprintf(stderr, "  %s %s\n", name, field.Message)
+	}
+	if len(response.Error.Fields) > 0 {
+		return 2
+	}
+	return 1
+}
+
+// runServe runs the gin server over the Handler of the Lambda
+func runServe(args []string, stderr io.Writer) int {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	addr := flags.String("addr", ":3000", "address to listen on")
+	if err := flags.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+	fmt.Fprintf(stderr, "serving on %s, swagger UI at /swagger/index.html\n", *addr)
+	if err := controller.SetupRouter(service, Handler).Run(*addr); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	return 0
+}
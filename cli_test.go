@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"shardanalyzer/analyzer"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeAnalyzer records the requests of the analyze command and answers them with the result or error
+type fakeAnalyzer struct {
+	requests     []analyzer.Request
+	contentTypes []string
+	err          error
+}
+
+func (f *fakeAnalyzer) Analyze(ctx context.Context, request analyzer.Request, contentType string) (analyzer.Result, error) {
+	f.requests = append(f.requests, request)
+	f.contentTypes = append(f.contentTypes, contentType)
+	if f.err != nil {
+		return analyzer.Result{}, f.err
+	}
+	return analyzer.Result{ContentType: contentType, Body: []byte("report of " + request.ClusterName)}, nil
+}
+
+func Test_runCommand(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join(dir, "shards.txt")
+	assert.NoError(t, os.WriteFile(input, []byte("from the file"), 0o600))
+	invalid := &analyzer.Error{Status: 400, Message: "invalid request", Fields: []analyzer.FieldError{{Field: "clustername", Message: "is required"}}}
+
+	tests := []struct {
+		name        string
+		args        []string
+		err         error
+		code        int
+		request     *analyzer.Request // expected request, nil when the command doesn't analyze
+		contentType string
+		stdout      string
+		stderr      string
+	}{
+		{name: "help", args: []string{"help"}, code: 0, stdout: "Usage: shardanalyzer <command>"},
+		{name: "unknown command", args: []string{"analyse"}, code: 2, stderr: `unknown command "analyse"`},
+		{
+			name: "stdin with the defaults", args: []string{"analyze", "-cluster-name", "prod"}, code: 0,
+			request:     &analyzer.Request{ClusterName: "prod", AvailabilityZones: 3, CatShards: "from stdin"},
+			contentType: "text/plain", stdout: "report of prod",
+		},
+		{
+			name: "stdin as -", args: []string{"analyze", "-cluster-name", "prod", "-format", "markdown", "-"}, code: 0,
+			request:     &analyzer.Request{ClusterName: "prod", AvailabilityZones: 3, CatShards: "from stdin"},
+			contentType: "text/markdown", stdout: "report of prod",
+		},
+		{
+			name: "file with flags", code: 0,
+			args: []string{"analyze", "-cluster-name", "prod", "-client-name", "acme", "-target-size", "30", "-target-docs", "1000",
+				"-azs", "2", "-workload", "search", "-locale", "de", "-format", "JSON", input},
+			request:     &analyzer.Request{ClusterName: "prod", ClientName: "acme", TargetSize: 30, TargetDocs: 1000, AvailabilityZones: 2, Search: true, Locale: "de", CatShards: "from the file"},
+			contentType: "application/json", stdout: "report of prod",
+		},
+		{name: "missing file", args: []string{"analyze", "-cluster-name", "prod", filepath.Join(dir, "missing.txt")}, code: 1, stderr: "no such file"},
+		{name: "two files", args: []string{"analyze", input, input}, code: 2, stderr: "Usage: shardanalyzer analyze"},
+		{name: "unknown format", args: []string{"analyze", "-format", "docx"}, code: 2, stderr: `-format must be table, json, pdf, markdown, html, csv or xlsx, not "docx"`},
+		{name: "unknown workload", args: []string{"analyze", "-workload", "metrics"}, code: 2, stderr: `-workload must be logs or search, not "metrics"`},
+		{name: "unknown flag", args: []string{"analyze", "-shards", "3"}, code: 2, stderr: "flag provided but not defined: -shards"},
+		{name: "invalid number", args: []string{"analyze", "-azs", "three"}, code: 2, stderr: `invalid value "three" for flag -azs`},
+		{name: "flag help", args: []string{"analyze", "-h"}, code: 0, stderr: "Usage: shardanalyzer analyze"},
+		{
+			name: "invalid request", args: []string{"analyze"}, err: invalid, code: 2,
+			request: &analyzer.Request{AvailabilityZones: 3, CatShards: "from stdin"}, contentType: "text/plain",
+			stderr: "ERROR: invalid request\n  -cluster-name is required\n",
+		},
+		{
+			name: "failed analysis", args: []string{"analyze", "-cluster-name", "prod"}, err: errors.New("out of memory"), code: 1,
+			request: &analyzer.Request{ClusterName: "prod", AvailabilityZones: 3, CatShards: "from stdin"}, contentType: "text/plain",
+			stderr: "ERROR: ",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake := &fakeAnalyzer{err: test.err}
+			analyzeCommand = fake.Analyze
+			defer func() { analyzeCommand = service.Analyze }()
+			var stdout, stderr bytes.Buffer
+
+			code := runCommand(test.args, strings.NewReader("from stdin"), &stdout, &stderr)
+
+			assert.Equal(t, test.code, code, stderr.String())
+			if test.request == nil {
+				assert.Empty(t, fake.requests)
+			} else if assert.Len(t, fake.requests, 1) {
+				assert.Equal(t, *test.request, fake.requests[0])
+				assert.Equal(t, test.contentType, fake.contentTypes[0])
+			}
+			assert.Contains(t, stdout.String(), test.stdout)
+			assert.Contains(t, stderr.String(), test.stderr)
+		})
+	}
+}
+
+func Test_runCommandWritesTheOutputFile(t *testing.T) {
+	analyzeCommand = (&fakeAnalyzer{}).Analyze
+	defer func() { analyzeCommand = service.Analyze }()
+	output := filepath.Join(t.TempDir(), "report.pdf")
+	var stdout, stderr bytes.Buffer
+
+	code := runCommand([]string{"analyze", "-cluster-name", "prod", "-format", "pdf", "-output", output}, strings.NewReader("from stdin"), &stdout, &stderr)
+
+	assert.Equal(t, 0, code)
+	assert.Empty(t, stdout.String())
+	report, err := os.ReadFile(output)
+	assert.NoError(t, err)
+	assert.Equal(t, "report of prod", string(report))
+}
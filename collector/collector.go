@@ -0,0 +1,219 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+)
+
+// cat APIs fetched from the domain, sizes in bytes so nothing is lost to rounding
+const (
+	CatShardsPath  = "_cat/shards?v&bytes=b"
+	CatIndicesPath = "_cat/indices?v&bytes=b"
+	CatNodesPath   = "_cat/nodes?v&h=name,ip,node.role"
+	CatAliasesPath = "_cat/aliases?v"
+	// index names only, to split the shards by index pattern
+	CatIndexNamesPath = "_cat/indices?v&h=index"
+)
+
+// CatShardsPatternPath lists the shards of the indices matching the pattern, the digits of
+// models.GetIndexPattern are wildcards.
+func CatShardsPatternPath(pattern string) string {
+	return "_cat/shards/" + pattern + "?v&bytes=b"
+}
+
+const (
+	DefaultTimeout      = 30 * time.Second
+	DefaultMaxRetries   = 3
+	DefaultBackoff      = 500 * time.Millisecond
+	MaxBackoff          = 10 * time.Second
+	DefaultMaxBodyBytes = 256 * 1024 * 1024
+	maxErrorBodyBytes   = 64 * 1024
+)
+
+type Options struct {
+	Endpoint string
+	Username string // basic auth is used when both username and password are set, requests are signed otherwise
+	Password string
+	Region   string // taken from the endpoint when empty
+	Service  string // es or aoss, taken from the endpoint when empty
+	RoleArn  string // role assumed for signing, the default credential chain is used when empty
+	// Credentials replaces the default credential chain, mostly for tests
+	Credentials aws.CredentialsProvider
+	// Client replaces the client built from the options below
+	Client             *http.Client
+	Timeout            time.Duration // of connecting and of waiting for the headers of a response, DefaultTimeout when 0
+	CACert             string        // PEM certificates trusted on top of the system ones
+	InsecureSkipVerify bool
+	ProxyURL           string        // the proxy of the environment is used when empty
+	MaxRetries         int           // retries of 429 and 5xx responses, DefaultMaxRetries when 0, negative disables
+	Backoff            time.Duration // first wait between retries, doubled for each retry, DefaultBackoff when 0
+	MaxBodyBytes       int64         // DefaultMaxBodyBytes when 0
+}
+
+// Collector fetches the cat APIs of a domain.
+type Collector struct {
+	endpoint     string
+	username     string
+	password     string
+	region       string
+	service      string
+	credentials  aws.CredentialsProvider
+	signer       *v4.Signer
+	client       *http.Client
+	maxRetries   int
+	backoff      time.Duration
+	maxBodyBytes int64
+}
+
+func New(ctx context.Context, opts Options) (*Collector, error) {
+	endpoint := normalizeEndpoint(opts.Endpoint)
+	if endpoint == "" {
+		return nil, &Error{Kind: InvalidOptions, Err: errors.New("empty domain endpoint")}
+	}
+	c := &Collector{
+		endpoint:     endpoint,
+		username:     opts.Username,
+		password:     opts.Password,
+		client:       opts.Client,
+		maxRetries:   opts.MaxRetries,
+		backoff:      opts.Backoff,
+		maxBodyBytes: opts.MaxBodyBytes,
+	}
+	if c.maxRetries == 0 {
+		c.maxRetries = DefaultMaxRetries
+	}
+	if c.backoff == 0 {
+		c.backoff = DefaultBackoff
+	}
+	if c.maxBodyBytes == 0 {
+		c.maxBodyBytes = DefaultMaxBodyBytes
+	}
+	if c.client == nil {
+		client, err := newHTTPClient(opts)
+		if err != nil {
+			return nil, err
+		}
+		c.client = client
+	}
+	if c.useBasicAuth() {
+		return c, nil
+	}
+
+	region, service := parseEndpoint(endpoint)
+	if opts.Region != "" {
+		region = opts.Region
+	}
+	if opts.Service != "" {
+		service = opts.Service
+	}
+	if region == "" {
+		return nil, &Error{Kind: InvalidOptions, Err: fmt.Errorf("no region in domain endpoint %s, please provide one", endpoint)}
+	}
+	c.region, c.service = region, service
+	c.credentials = opts.Credentials
+	if c.credentials == nil {
+		credentials, err := loadCredentials(ctx, region, opts.RoleArn)
+		if err != nil {
+			return nil, err
+		}
+		c.credentials = credentials
+	}
+	c.signer = v4.NewSigner()
+	return c, nil
+}
+
+// Stream sends a GET request for the path and hands the body of a 2xx response to read while it
+// arrives, so large responses are never held in memory. Throttled and failed requests are retried
+// with an exponential backoff, or after the Retry-After of the response, before read is called.
+func (c *Collector) Stream(ctx context.Context, path string, read func(io.Reader) error) error {
+	for attempt := 0; ; attempt++ {
+		resp, retryAfter, err := c.send(ctx, path)
+		if err == nil {
+			defer resp.Body.Close()
+			body := &bodyReader{ctx: ctx, path: path, reader: resp.Body, remaining: c.maxBodyBytes}
+			readError := read(body)
+			if body.err != nil {
+				// the parsers may hide the error of the body behind their own
+				return body.err
+			}
+			return readError
+		}
+		if attempt >= c.maxRetries || !err.retryable() {
+			return err
+		}
+		wait := c.getBackoff(attempt)
+		if retryAfter > wait {
+			wait = retryAfter
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return &Error{Kind: Timeout, Path: path, Err: ctx.Err()}
+		}
+	}
+}
+
+// send sends the request once and returns the response of a 2xx status, a new request is signed
+// for every attempt so the signature stays fresh.
+func (c *Collector) send(ctx context.Context, path string) (*http.Response, time.Duration, *Error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.endpoint+"/"+path, nil)
+	if err != nil {
+		return nil, 0, &Error{Kind: InvalidOptions, Path: path, Err: err}
+	}
+	if authError := c.authorize(ctx, req); authError != nil {
+		authError.Path = path
+		return nil, 0, authError
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, 0, transportError(ctx, path, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		// error bodies are only kept for the message
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+		return nil, parseRetryAfter(resp.Header.Get("Retry-After")), statusError(path, resp.StatusCode, string(body))
+	}
+	return resp, 0, nil
+}
+
+func (c *Collector) getBackoff(attempt int) time.Duration {
+	backoff := c.backoff << attempt
+	if backoff > MaxBackoff || backoff <= 0 {
+		return MaxBackoff
+	}
+	return backoff
+}
+
+// parseRetryAfter reads the seconds form of the header, waits beyond MaxBackoff are capped.
+func parseRetryAfter(header string) time.Duration {
+	seconds, err := strconv.Atoi(strings.TrimSpace(header))
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	if wait := time.Duration(seconds) * time.Second; wait < MaxBackoff {
+		return wait
+	}
+	return MaxBackoff
+}
+
+func (c *Collector) useBasicAuth() bool {
+	return c.username != "" && c.password != ""
+}
+
+func normalizeEndpoint(endpoint string) string {
+	endpoint = strings.TrimRight(strings.TrimSpace(endpoint), "/")
+	if endpoint != "" && !strings.Contains(endpoint, "://") {
+		endpoint = "https://" + endpoint
+	}
+	return endpoint
+}
@@ -0,0 +1,243 @@
+package collector
+
+import (
+	"context"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/stretchr/testify/assert"
+)
+
+var catResponses = map[string]string{
+	"/_cat/shards":  "index shard prirep state docs store ip node\nlogs-2022.01.01 0 p STARTED 10 1024 10.0.0.1 node-1\n",
+	"/_cat/indices": "health status index uuid pri rep docs.count docs.deleted store.size pri.store.size\n",
+	"/_cat/nodes":   "name ip node.role\nnode-1 10.0.0.1 dir\n",
+	"/_cat/aliases": "alias index filter routing.index routing.search is_write_index\n",
+}
+
+func newTestDomain(check func(r *http.Request)) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		check(r)
+		body, ok := catResponses[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+}
+
+// get streams the body of the path into a string
+func get(ctx context.Context, c *Collector, path string) (body string, err error) {
+	err = c.Stream(ctx, path, func(r io.Reader) error {
+		data, err := io.ReadAll(r)
+		body = string(data)
+		return err
+	})
+	return
+}
+
+func Test_collectSigned(t *testing.T) {
+	server := newTestDomain(func(r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		assert.True(t, strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/"), auth)
+		assert.Contains(t, auth, "/eu-west-1/es/aws4_request")
+		assert.NotEmpty(t, r.Header.Get("X-Amz-Date"))
+		assert.Equal(t, "token", r.Header.Get("X-Amz-Security-Token"))
+		assert.Equal(t, emptyPayloadHash, r.Header.Get("X-Amz-Content-Sha256"))
+	})
+	defer server.Close()
+
+	c, err := New(context.Background(), Options{
+		Endpoint:    server.URL + "/",
+		Region:      "eu-west-1",
+		Credentials: credentials.NewStaticCredentialsProvider("AKID", "SECRET", "token"),
+	})
+	assert.NoError(t, err)
+	for _, path := range []string{CatShardsPath, CatIndicesPath, CatNodesPath, CatAliasesPath} {
+		body, err := get(context.Background(), c, path)
+		assert.NoError(t, err)
+		assert.Equal(t, catResponses["/"+strings.Split(path, "?")[0]], body)
+	}
+}
+
+func Test_collectBasicAuth(t *testing.T) {
+	server := newTestDomain(func(r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, "admin", username)
+		assert.Equal(t, "secret", password)
+	})
+	defer server.Close()
+
+	c, err := New(context.Background(), Options{Endpoint: server.URL, Username: "admin", Password: "secret"})
+	assert.NoError(t, err)
+	_, err = get(context.Background(), c, CatShardsPath)
+	assert.NoError(t, err)
+
+	_, err = get(context.Background(), c, "_cat/missing")
+	assert.ErrorContains(t, err, "status 404")
+}
+
+func Test_parseEndpoint(t *testing.T) {
+	region, service := parseEndpoint("https://search-logs-abc123.us-east-1.es.amazonaws.com")
+	assert.Equal(t, "us-east-1", region)
+	assert.Equal(t, ServiceOpenSearch, service)
+	region, service = parseEndpoint("https://abc123.ap-southeast-2.aoss.amazonaws.com")
+	assert.Equal(t, "ap-southeast-2", region)
+	assert.Equal(t, ServiceOpenSearchServerless, service)
+	region, _ = parseEndpoint("https://search.example.com")
+	assert.Empty(t, region)
+
+	_, err := New(context.Background(), Options{Endpoint: "https://search.example.com"})
+	assert.ErrorContains(t, err, "no region")
+	_, err = New(context.Background(), Options{Endpoint: " "})
+	assert.Error(t, err)
+}
+
+func Test_getRetriesThrottling(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts < 3 || r.URL.Path == "/throttled" {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+
+	c, err := New(context.Background(), Options{Endpoint: server.URL, Username: "u", Password: "p", Backoff: time.Millisecond})
+	assert.NoError(t, err)
+	body, err := get(context.Background(), c, "_cat/shards")
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", body)
+	assert.Equal(t, 3, attempts)
+
+	attempts = 0
+	_, err = get(context.Background(), c, "throttled")
+	var collectorError *Error
+	assert.ErrorAs(t, err, &collectorError)
+	assert.Equal(t, BadResponse, collectorError.Kind)
+	assert.Equal(t, http.StatusTooManyRequests, collectorError.StatusCode)
+	assert.Equal(t, http.StatusBadGateway, HTTPStatus(err))
+	assert.Equal(t, 1+DefaultMaxRetries, attempts)
+}
+
+func Test_getErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/forbidden":
+			w.WriteHeader(http.StatusForbidden)
+		case "/slow":
+			time.Sleep(100 * time.Millisecond)
+		}
+		w.Write([]byte("0123456789"))
+	}))
+	defer server.Close()
+
+	c, err := New(context.Background(), Options{Endpoint: server.URL, Username: "u", Password: "p", MaxBodyBytes: 10})
+	assert.NoError(t, err)
+	_, err = get(context.Background(), c, "forbidden")
+	assert.Equal(t, http.StatusForbidden, HTTPStatus(err))
+
+	body, err := get(context.Background(), c, "exact")
+	assert.NoError(t, err)
+	assert.Equal(t, "0123456789", body)
+	c.maxBodyBytes = 9
+	_, err = get(context.Background(), c, "large")
+	var collectorError *Error
+	assert.ErrorAs(t, err, &collectorError)
+	assert.Equal(t, BodyTooLarge, collectorError.Kind)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = get(ctx, c, "slow")
+	assert.Equal(t, http.StatusGatewayTimeout, HTTPStatus(err))
+
+	_, err = New(context.Background(), Options{Endpoint: server.URL, ProxyURL: "::"})
+	assert.Equal(t, http.StatusBadRequest, HTTPStatus(err))
+	_, err = New(context.Background(), Options{Endpoint: server.URL, CACert: "not a certificate"})
+	assert.Equal(t, http.StatusBadRequest, HTTPStatus(err))
+}
+
+func Test_timeoutEndsWaitingForHeadersOnly(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow-headers" {
+			time.Sleep(150 * time.Millisecond)
+		}
+		// a large body keeps coming for longer than the timeout
+		for i := 0; i < 3; i++ {
+			w.Write([]byte("01234"))
+			w.(http.Flusher).Flush()
+			time.Sleep(50 * time.Millisecond)
+		}
+	}))
+	defer server.Close()
+
+	c, err := New(context.Background(), Options{Endpoint: server.URL, Username: "u", Password: "p", Timeout: 75 * time.Millisecond, MaxRetries: -1})
+	assert.NoError(t, err)
+	body, err := get(context.Background(), c, "slow-body")
+	assert.NoError(t, err)
+	assert.Equal(t, "012340123401234", body)
+	_, err = get(context.Background(), c, "slow-headers")
+	assert.Equal(t, http.StatusGatewayTimeout, HTTPStatus(err))
+}
+
+func Test_tlsOptions(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	defer server.Close()
+	caCert := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}))
+
+	untrusted, err := New(context.Background(), Options{Endpoint: server.URL, Username: "u", Password: "p"})
+	assert.NoError(t, err)
+	_, err = get(context.Background(), untrusted, "")
+	assert.Equal(t, http.StatusBadGateway, HTTPStatus(err))
+
+	for _, opts := range []Options{{CACert: caCert}, {InsecureSkipVerify: true}} {
+		opts.Endpoint, opts.Username, opts.Password = server.URL, "u", "p"
+		c, err := New(context.Background(), opts)
+		assert.NoError(t, err)
+		body, err := get(context.Background(), c, "")
+		assert.NoError(t, err)
+		assert.Equal(t, "ok", body)
+	}
+}
+
+func Test_proxy(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// proxies get the absolute url of the domain
+		w.Write([]byte(r.URL.String()))
+	}))
+	defer proxy.Close()
+
+	c, err := New(context.Background(), Options{Endpoint: "http://domain.internal", Username: "u", Password: "p", ProxyURL: proxy.URL})
+	assert.NoError(t, err)
+	body, err := get(context.Background(), c, CatNodesPath)
+	assert.NoError(t, err)
+	assert.Equal(t, "http://domain.internal/"+CatNodesPath, body)
+}
+
+func Test_withReservedTime(t *testing.T) {
+	deadline := time.Now().Add(time.Minute)
+	parent, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	ctx, cancel := WithReservedTime(parent, 5*time.Second)
+	defer cancel()
+	reserved, ok := ctx.Deadline()
+	assert.True(t, ok)
+	assert.Equal(t, deadline.Add(-5*time.Second), reserved)
+
+	ctx, cancel = WithReservedTime(context.Background(), 5*time.Second)
+	defer cancel()
+	_, ok = ctx.Deadline()
+	assert.False(t, ok)
+}
@@ -0,0 +1,109 @@
+package collector
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+type ErrorKind int
+
+const (
+	InvalidOptions     ErrorKind = iota // endpoint, CA or proxy of the request are wrong
+	CredentialsFailure                  // no AWS credentials to sign with
+	Unreachable                         // the request never got a response
+	Timeout                             // the deadline passed before the domain answered
+	Unauthorized                        // the domain answered 401 or 403
+	BadResponse                         // the domain answered with another non 2xx status
+	BodyTooLarge                        // the response is larger than the body limit
+)
+
+var kindNames = map[ErrorKind]string{
+	InvalidOptions:     "invalid options",
+	CredentialsFailure: "credentials failure",
+	Unreachable:        "domain unreachable",
+	Timeout:            "timeout",
+	Unauthorized:       "unauthorized",
+	BadResponse:        "bad response",
+	BodyTooLarge:       "body too large",
+}
+
+func (k ErrorKind) String() string {
+	return kindNames[k]
+}
+
+// Error is returned by everything in this package, the Kind decides the status reported to the caller.
+type Error struct {
+	Kind       ErrorKind
+	Path       string // cat API of the failed request, empty when no request was sent
+	StatusCode int    // status of the domain response, 0 without one
+	Body       string // body of the domain response for non 2xx statuses
+	Err        error
+}
+
+func (e *Error) Error() string {
+	msg := e.Kind.String()
+	if e.Path != "" {
+		msg += " for " + e.Path
+	}
+	if e.StatusCode != 0 {
+		msg += fmt.Sprintf(": status %d: %s", e.StatusCode, e.Body)
+	}
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// HTTPStatus is the status the handlers answer with.
+func (e *Error) HTTPStatus() int {
+	switch e.Kind {
+	case InvalidOptions:
+		return http.StatusBadRequest
+	case CredentialsFailure:
+		return http.StatusInternalServerError
+	case Timeout:
+		return http.StatusGatewayTimeout
+	case Unauthorized:
+		return http.StatusForbidden
+	default:
+		return http.StatusBadGateway
+	}
+}
+
+// HTTPStatus maps errors of this package to their status, anything else is an internal error.
+func HTTPStatus(err error) int {
+	var collectorError *Error
+	if errors.As(err, &collectorError) {
+		return collectorError.HTTPStatus()
+	}
+	return http.StatusInternalServerError
+}
+
+// retryable is true for throttling and server side failures.
+func (e *Error) retryable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// transportError tells timeouts apart from other failures of sending or reading.
+func transportError(ctx context.Context, path string, err error) *Error {
+	var netError net.Error
+	if ctx.Err() != nil || errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netError) && netError.Timeout()) {
+		return &Error{Kind: Timeout, Path: path, Err: err}
+	}
+	return &Error{Kind: Unreachable, Path: path, Err: err}
+}
+
+func statusError(path string, statusCode int, body string) *Error {
+	kind := BadResponse
+	if statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden {
+		kind = Unauthorized
+	}
+	return &Error{Kind: kind, Path: path, StatusCode: statusCode, Body: body}
+}
@@ -0,0 +1,75 @@
+package collector
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+)
+
+const (
+	ServiceOpenSearch           = "es"
+	ServiceOpenSearchServerless = "aoss"
+)
+
+// GET requests have no body, this is the hash of the empty payload
+var emptyPayloadHash = func() string {
+	sum := sha256.Sum256(nil)
+	return hex.EncodeToString(sum[:])
+}()
+
+// search-domain-abc.us-east-1.es.amazonaws.com or abc.us-east-1.aoss.amazonaws.com
+var endpointRegex = regexp.MustCompile(`\.([a-z]{2}(?:-[a-z]+)+-\d+)\.(es|aoss)\.amazonaws\.com`)
+
+// parseEndpoint returns the region and service of an AWS endpoint, the region is empty for
+// custom endpoints.
+func parseEndpoint(endpoint string) (region string, service string) {
+	match := endpointRegex.FindStringSubmatch(endpoint)
+	if match == nil {
+		return "", ServiceOpenSearch
+	}
+	return match[1], match[2]
+}
+
+// loadCredentials uses the default credential chain, or the role when one is given.
+func loadCredentials(ctx context.Context, region string, roleArn string) (aws.CredentialsProvider, error) {
+	cfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(region))
+	if err != nil {
+		return nil, &Error{Kind: CredentialsFailure, Err: fmt.Errorf("loading AWS config: %w", err)}
+	}
+	if roleArn == "" {
+		return cfg.Credentials, nil
+	}
+	provider := stscreds.NewAssumeRoleProvider(sts.NewFromConfig(cfg), roleArn, func(o *stscreds.AssumeRoleOptions) {
+		o.RoleSessionName = "shard-analyzer"
+	})
+	return aws.NewCredentialsCache(provider), nil
+}
+
+func (c *Collector) authorize(ctx context.Context, req *http.Request) *Error {
+	if c.useBasicAuth() {
+		req.SetBasicAuth(c.username, c.password)
+		return nil
+	}
+	creds, err := c.credentials.Retrieve(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return &Error{Kind: Timeout, Err: err}
+		}
+		return &Error{Kind: CredentialsFailure, Err: fmt.Errorf("retrieving AWS credentials: %w", err)}
+	}
+	// serverless requires the payload hash header, managed domains accept it
+	req.Header.Set("X-Amz-Content-Sha256", emptyPayloadHash)
+	if err = c.signer.SignHTTP(ctx, creds, req, emptyPayloadHash, c.service, c.region, time.Now()); err != nil {
+		return &Error{Kind: CredentialsFailure, Err: fmt.Errorf("signing request: %w", err)}
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package collector
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// newHTTPClient applies the timeout, TLS and proxy options to a copy of the default transport. The
+// timeout ends connecting and waiting for the response headers only, large bodies are streamed for
+// as long as the context of the request allows.
+func newHTTPClient(opts Options) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if opts.ProxyURL != "" {
+		proxy, err := url.Parse(opts.ProxyURL)
+		if err != nil || proxy.Host == "" {
+			return nil, &Error{Kind: InvalidOptions, Err: fmt.Errorf("proxy url %q is not valid", opts.ProxyURL)}
+		}
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+	if opts.CACert != "" || opts.InsecureSkipVerify {
+		tlsConfig := &tls.Config{InsecureSkipVerify: opts.InsecureSkipVerify}
+		if opts.CACert != "" {
+			pool, err := x509.SystemCertPool()
+			if err != nil {
+				pool = x509.NewCertPool()
+			}
+			if !pool.AppendCertsFromPEM([]byte(opts.CACert)) {
+				return nil, &Error{Kind: InvalidOptions, Err: errors.New("no PEM certificate found in the CA certificate")}
+			}
+			tlsConfig.RootCAs = pool
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+	timeout := opts.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+	transport.DialContext = (&net.Dialer{Timeout: timeout, KeepAlive: 30 * time.Second}).DialContext
+	transport.TLSHandshakeTimeout = timeout
+	transport.ResponseHeaderTimeout = timeout
+	return &http.Client{Transport: transport}, nil
+}
+
+// WithReservedTime ends the context reserve before its deadline, so a Lambda still has time
+// to answer after the collection timed out. Contexts without a deadline are returned as they are.
+func WithReservedTime(ctx context.Context, reserve time.Duration) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return context.WithCancel(ctx)
+	}
+	return context.WithDeadline(ctx, deadline.Add(-reserve))
+}
+
+// bodyReader turns failures of reading a response into errors of this package and stops
+// reading bodies over the limit.
+type bodyReader struct {
+	ctx       context.Context
+	path      string
+	reader    io.Reader
+	remaining int64
+	err       *Error
+}
+
+func (b *bodyReader) Read(p []byte) (int, error) {
+	if b.err != nil {
+		return 0, b.err
+	}
+	// one byte over the limit tells a body of exactly the limit from a larger one
+	if int64(len(p)) > b.remaining+1 {
+		p = p[:b.remaining+1]
+	}
+	n, err := b.reader.Read(p)
+	b.remaining -= int64(n)
+	if b.remaining < 0 {
+		b.err = &Error{Kind: BodyTooLarge, Path: b.path, Err: errors.New("response is over the body limit")}
+		return 0, b.err
+	}
+	if err != nil && err != io.EOF {
+		b.err = transportError(b.ctx, b.path, err)
+		return n, b.err
+	}
+	return n, err
+}
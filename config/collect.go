@@ -0,0 +1,118 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+
+	"shardanalyzer/collector"
+	"shardanalyzer/models"
+)
+
+// CollectStats builds the cluster from a live domain. The cat APIs are parsed while their responses
+// are read, only the segments come from CatSegments.
+func (config *ShardRecommendationRequest) CollectStats(ctx context.Context, domain *collector.Collector) (cluster *models.Cluster, err error) {
+	if cluster, err = config.newCluster(); err != nil {
+		return
+	}
+	// aliases decide how the shards are grouped, so they go first
+	alias := models.AliasStats{}
+	err = streamOptional(ctx, domain, cluster, collector.CatAliasesPath, "alias ", &alias, func() {
+		cluster.AddAlias(alias)
+	}, "the indices are grouped by their names instead of their rollover aliases")
+	if err != nil {
+		return
+	}
+	if err = config.collectShards(ctx, domain, cluster); err != nil {
+		return
+	}
+	node := models.NodeInfo{}
+	err = streamOptional(ctx, domain, cluster, collector.CatNodesPath, "name ", &node, func() {
+		cluster.AddNode(node)
+	}, "the data nodes are counted from the shards")
+	if err != nil {
+		return
+	}
+	if err = config.parseSegments(cluster); err != nil {
+		return
+	}
+	index := models.IndexStats{}
+	err = streamLines(ctx, domain, collector.CatIndicesPath, "health ", &index, func() {
+		cluster.AddIndexStats(index)
+	})
+	return
+}
+
+// collectShards fetches all shards at once, or one index pattern at a time with ShardsPerPattern.
+func (config *ShardRecommendationRequest) collectShards(ctx context.Context, domain *collector.Collector, cluster *models.Cluster) error {
+	shard := models.ShardStats{}
+	if !config.ShardsPerPattern {
+		return streamLines(ctx, domain, collector.CatShardsPath, "index ", &shard, func() {
+			cluster.Add(shard)
+		})
+	}
+	patterns, err := collectIndexPatterns(ctx, domain)
+	if err != nil {
+		return err
+	}
+	for _, pattern := range patterns {
+		err = streamLines(ctx, domain, collector.CatShardsPatternPath(pattern), "index ", &shard, func() {
+			// the wildcards may match indices of other patterns, their own request adds them
+			if models.GetIndexPattern(shard.Index) == pattern {
+				cluster.Add(shard)
+			}
+		})
+		var collectorError *collector.Error
+		if errors.As(err, &collectorError) && collectorError.StatusCode == http.StatusNotFound {
+			// patterns without digits are index names, the index was deleted since it was listed
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func collectIndexPatterns(ctx context.Context, domain *collector.Collector) ([]string, error) {
+	index := struct {
+		Name string `tsv:"index"`
+	}{}
+	seen := map[string]bool{}
+	patterns := []string{}
+	err := streamLines(ctx, domain, collector.CatIndexNamesPath, "index", &index, func() {
+		pattern := models.GetIndexPattern(index.Name)
+		if !seen[pattern] {
+			seen[pattern] = true
+			patterns = append(patterns, pattern)
+		}
+	})
+	sort.Strings(patterns)
+	return patterns, err
+}
+
+// streamOptional streams a cat API that only adds to the report. When the domain refuses it, for
+// example under fine-grained access control, it is noted on the cluster and the collection goes on.
+func streamOptional(ctx context.Context, domain *collector.Collector, cluster *models.Cluster, path string, headerPrefix string, data interface{}, add func(), effect string) error {
+	err := streamLines(ctx, domain, path, headerPrefix, data, add)
+	var collectorError *collector.Error
+	if errors.As(err, &collectorError) && (collectorError.Kind == collector.Unauthorized || collectorError.Kind == collector.BadResponse) {
+		cluster.SkippedInputs = append(cluster.SkippedInputs, models.SkippedInput{
+			Path:   strings.SplitN(path, "?", 2)[0],
+			Reason: fmt.Sprintf("status %d", collectorError.StatusCode),
+			Effect: effect,
+		})
+		return nil
+	}
+	return err
+}
+
+func streamLines(ctx context.Context, domain *collector.Collector, path string, headerPrefix string, data interface{}, add func()) error {
+	return domain.Stream(ctx, path, func(body io.Reader) error {
+		return parseReader(body, headerPrefix, data, add)
+	})
+}
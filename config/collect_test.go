@@ -0,0 +1,169 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"shardanalyzer/collector"
+	"shardanalyzer/models"
+)
+
+const catMetricShards = `metrics-2022.01.01 0 p STARTED 500 2gb 10.0.0.1 node-1
+metrics-2022.01.02 0 p STARTED 500 2gb 10.0.0.2 node-2
+`
+
+// newTestDomain answers like a domain holding the shards of catShards and catMetricShards, the
+// shards of a pattern request are all of them, as if the wildcards matched every index.
+func newTestDomain(requests *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests = append(*requests, r.URL.Path)
+		switch {
+		case r.URL.Path == "/_cat/aliases":
+			w.Write([]byte(catAliases))
+		case r.URL.Path == "/_cat/shards/deleted-index":
+			w.WriteHeader(http.StatusNotFound)
+		case strings.HasPrefix(r.URL.Path, "/_cat/shards"):
+			w.Write([]byte(catShards + catMetricShards))
+		case r.URL.Path == "/_cat/nodes":
+			w.Write([]byte(catNodes))
+		case r.URL.Path == "/_cat/indices" && r.URL.Query().Get("h") == "index":
+			w.Write([]byte("index\nlogs-000001\nlogs-000002\nlogs-000003\nmetrics-2022.01.01\nmetrics-2022.01.02\ndeleted-index\n"))
+		case r.URL.Path == "/_cat/indices":
+			w.Write([]byte(catIndices))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func Test_collectStats(t *testing.T) {
+	expected, err := (&ShardRecommendationRequest{
+		CatShards:  catShards + catMetricShards,
+		CatAliases: catAliases,
+		CatNodes:   catNodes,
+		CatIndices: catIndices,
+	}).ParseStats()
+	assert.NoError(t, err)
+
+	for _, perPattern := range []bool{false, true} {
+		var requests []string
+		server := newTestDomain(&requests)
+		domain, err := collector.New(context.Background(), collector.Options{Endpoint: server.URL, Username: "u", Password: "p"})
+		assert.NoError(t, err)
+		args := ShardRecommendationRequest{ShardsPerPattern: perPattern}
+		cluster, err := args.CollectStats(context.Background(), domain)
+		server.Close()
+		assert.NoError(t, err)
+
+		assert.Equal(t, expected.TotalPrimarySizeBytes, cluster.TotalPrimarySizeBytes)
+		assert.Equal(t, expected.TotalReplicaSizeBytes, cluster.TotalReplicaSizeBytes)
+		assert.Len(t, cluster.Nodes, 3)
+		assert.Len(t, cluster.Rollup, 2)
+		assert.Equal(t, "logs-000003", cluster.Rollup["logs"].GetWriteIndex())
+		assert.Len(t, cluster.ClosedIndices, 1)
+		if perPattern {
+			assert.Contains(t, requests, "/_cat/shards/logs-******")
+			assert.Contains(t, requests, "/_cat/shards/metrics-****.**.**")
+			assert.Contains(t, requests, "/_cat/shards/deleted-index")
+		} else {
+			assert.Contains(t, requests, "/_cat/shards")
+		}
+	}
+}
+
+func Test_collectStatsWithoutAliasesAndNodes(t *testing.T) {
+	var requests []string
+	domainServer := newTestDomain(&requests)
+	defer domainServer.Close()
+	// fine-grained access control refusing the cat APIs that only add to the report
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/_cat/aliases" || r.URL.Path == "/_cat/nodes" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		domainServer.Config.Handler.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	domain, err := collector.New(context.Background(), collector.Options{Endpoint: server.URL, Username: "u", Password: "p"})
+	assert.NoError(t, err)
+	args := ShardRecommendationRequest{TargetShardSizeGB: 30, NumberOfAzs: 3}
+	cluster, err := args.CollectStats(context.Background(), domain)
+	assert.NoError(t, err)
+
+	assert.Empty(t, cluster.Aliases)
+	assert.Len(t, cluster.Nodes, 2, "the nodes holding shards, without the node of no shards from _cat/nodes")
+	assert.Equal(t, []models.SkippedInput{
+		{Path: "_cat/aliases", Reason: "status 403", Effect: "the indices are grouped by their names instead of their rollover aliases"},
+		{Path: "_cat/nodes", Reason: "status 403", Effect: "the data nodes are counted from the shards"},
+	}, cluster.SkippedInputs)
+	reco := cluster.PrepareRecommendation()
+	assert.Equal(t, cluster.SkippedInputs, reco.SkippedInputs)
+	var skipped []models.Finding
+	for _, finding := range reco.Findings {
+		if finding.Type == models.FindingSkippedInput {
+			skipped = append(skipped, finding)
+		}
+	}
+	if assert.Len(t, skipped, 2) {
+		assert.Equal(t, models.SeverityWarning, skipped[0].Severity)
+		assert.Equal(t, "_cat/aliases could not be read (status 403), the indices are grouped by their names instead of their rollover aliases", skipped[0].Message)
+		assert.Equal(t, "Allow GET /_cat/aliases for the user or role of the analyzer", skipped[0].Remediation)
+	}
+}
+
+// syntheticCatShards lists shards of daily indices with 5 primaries and a replica each.
+func syntheticCatShards(shards int) string {
+	var b strings.Builder
+	b.WriteString("index shard prirep state docs store ip node\n")
+	for i := 0; i < shards/10; i++ {
+		index := fmt.Sprintf("app%d-logs-2022.%02d.%02d", i%20, i/20%12+1, i/240%28+1)
+		for shard := 0; shard < 5; shard++ {
+			for _, prirep := range []string{"p", "r"} {
+				node := (i + shard) % 30
+				fmt.Fprintf(&b, "%s %d %s STARTED %d %d 10.0.0.%d node-%d\n", index, shard, prirep, 100000+i, 1073741824+int64(i)*1024, node, node)
+			}
+		}
+	}
+	return b.String()
+}
+
+func Benchmark_parseStats100kShards(b *testing.B) {
+	input := syntheticCatShards(100000)
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		args := ShardRecommendationRequest{CatShards: input, TargetShardSizeGB: 30, NumberOfAzs: 3}
+		if _, err := args.ParseStats(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func Benchmark_collectStats100kShards(b *testing.B) {
+	input := []byte(syntheticCatShards(100000))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/_cat/shards" {
+			w.Write(input)
+		}
+	}))
+	defer server.Close()
+	domain, err := collector.New(context.Background(), collector.Options{Endpoint: server.URL, Username: "u", Password: "p"})
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(int64(len(input)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		args := ShardRecommendationRequest{TargetShardSizeGB: 30, NumberOfAzs: 3}
+		if _, err := args.CollectStats(context.Background(), domain); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
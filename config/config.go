@@ -1,57 +1,206 @@
 package config
 
 import (
+	"bufio"
 	"errors"
 	"fmt"
+	"io"
 	"shardanalyzer/models"
 	"shardanalyzer/parser"
 	"strings"
+
+	log "github.com/sirupsen/logrus"
 )
 
 type ShardRecommendationRequest struct {
-	CatShards         string
-	TargetShardSizeGB int
-	NumberOfAzs       int
-	IsSearchWorkload  bool
-	ClusterName       string
-	ClientName        string
+	CatShards          string
+	CatAliases         string // optional output of _cat/aliases?v, groups indices by rollover alias
+	CatSegments        string // optional output of _cat/segments?v, for the forcemerge analysis
+	CatIndices         string // optional output of _cat/indices?v, for the deleted docs and closed indices
+	CatNodes           string // optional output of _cat/nodes?v&h=name,ip,node.role, counts data nodes without shards
+	ShardsPerPattern   bool   // CollectStats fetches _cat/shards one index pattern at a time, for very large clusters
+	TargetShardSizeGB  int
+	TargetDocsPerShard int64 // optional, shards are sized for whichever of size and docs needs more of them
+	NumberOfAzs        int
+	IsSearchWorkload   bool
+	ClusterName        string
+	ClientName         string
+	CleanupMaxDocs     int64 // indices with fewer docs are listed for cleanup, 0 disables
+	CleanupMaxSizeMB   int   // indices with a smaller primary size are listed for cleanup, 0 disables
+	DeductCleanup      bool  // remove cleanup candidates from the potential shard count
+	// exclude, report or analyze, empty values keep the defaults of models.NewIndexClassifier
+	SystemIndexPolicy     string
+	HiddenIndexPolicy     string
+	DataStreamIndexPolicy string
+	SystemIndexPatterns   []string // replaces models.DefaultSystemIndexPatterns when set
+	SkewFactor            float64  // largest to median shard ratio flagged as routing skew, 0 keeps models.DefaultSkewFactor
+	// finding thresholds, 0 keeps the defaults of models.NewFindingThresholds
+	LargeShardGB     int
+	SmallShardMB     int
+	MaxShardDocs     int64
+	DocLimitFraction float64 // share of the Lucene doc limit a shard is sized for, 0 keeps models.DefaultDocLimitFraction
 }
 
 func (config *ShardRecommendationRequest) ParseStats() (cluster *models.Cluster, err error) {
+	if cluster, err = config.newCluster(); err != nil {
+		return
+	}
+	shard := models.ShardStats{}
+	valid := validateInput(config.CatShards)
+	if !valid {
+		return cluster, errors.New("input is not a valid one. Please provide the output of _cat/shards?v as input")
+	}
+	// aliases decide how the shards are grouped, so they go first
+	if err = config.parseAliases(cluster); err != nil {
+		return
+	}
+	err = parseLines(config.CatShards, "index ", &shard, func() {
+		cluster.Add(shard)
+	})
+	if err != nil {
+		return
+	}
+	node := models.NodeInfo{}
+	err = parseLines(config.CatNodes, "name ", &node, func() {
+		cluster.AddNode(node)
+	})
+	if err != nil {
+		return
+	}
+	if err = config.parseSegments(cluster); err != nil {
+		return
+	}
+	index := models.IndexStats{}
+	err = parseLines(config.CatIndices, "health ", &index, func() {
+		cluster.AddIndexStats(index)
+	})
+	return
+}
+
+func (config *ShardRecommendationRequest) newCluster() (cluster *models.Cluster, err error) {
 	cluster = &models.Cluster{
 		Name:                 config.ClusterName,
 		ClientName:           config.ClientName,
 		IsSearchWorkload:     config.IsSearchWorkload,
 		NumberOfAZs:          config.NumberOfAzs,
 		RecommendedShardSize: config.TargetShardSizeGB,
+		TargetDocsPerShard:   config.TargetDocsPerShard,
+		CleanupMaxDocs:       config.CleanupMaxDocs,
+		CleanupMaxSizeBytes:  int64(config.CleanupMaxSizeMB) * 1024 * 1024,
+		DeductCleanupShards:  config.DeductCleanup,
+		SkewFactor:           config.SkewFactor,
+		DocLimitFraction:     config.DocLimitFraction,
 		Nodes:                map[string]*models.NodeStats{},
 		Rollup:               map[string]*models.IndexPatternRollup{},
+		Thresholds: models.FindingThresholds{
+			LargeShardGB: config.LargeShardGB,
+			SmallShardMB: config.SmallShardMB,
+			MaxShardDocs: config.MaxShardDocs,
+		},
 	}
+	cluster.Classifier, err = config.getIndexClassifier()
+	return
+}
 
-	var contentReader = strings.NewReader(config.CatShards)
-	shard := models.ShardStats{}
-	var pars *parser.Parser
-	valid := validateInput(config.CatShards)
-	if !valid {
-		return cluster, errors.New("input is not a valid one. Please provide the output of _cat/shards?v as input")
+func (config *ShardRecommendationRequest) parseSegments(cluster *models.Cluster) error {
+	segment := models.AllocationStats{}
+	return parseLines(config.CatSegments, "index ", &segment, func() {
+		cluster.AddSegment(segment)
+	})
+}
+
+func (config *ShardRecommendationRequest) parseAliases(cluster *models.Cluster) error {
+	alias := models.AliasStats{}
+	return parseLines(config.CatAliases, "alias ", &alias, func() {
+		cluster.AddAlias(alias)
+	})
+}
+
+// parseLines calls add after each line of the input is read into data, see parseReader.
+func parseLines(input string, headerPrefix string, data interface{}, add func()) error {
+	if strings.TrimSpace(input) == "" {
+		return nil
 	}
-	if strings.HasPrefix(config.CatShards, "index ") {
-		pars, _ = parser.NewParser(contentReader, &shard)
+	return parseReader(strings.NewReader(input), headerPrefix, data, add)
+}
+
+// parseReader calls add after each line of the reader is read into data, lines with missing
+// information are skipped and counted in a single warning. The header is read when the input starts with headerPrefix, otherwise
+// the columns are expected in the field order of data. Lines are parsed as they are read, so
+// the input is never held in memory as a whole.
+func parseReader(reader io.Reader, headerPrefix string, data interface{}, add func()) error {
+	source := &sourceReader{reader: reader}
+	buffered := bufio.NewReader(source)
+	start, _ := buffered.Peek(len(headerPrefix))
+	if source.err != nil {
+		return source.err
+	}
+	if len(start) == 0 {
+		return nil
+	}
+	var pars *parser.Parser
+	if strings.HasPrefix(string(start), headerPrefix) {
+		var err error
+		if pars, err = parser.NewParser(buffered, data); err != nil {
+			return err
+		}
 	} else {
-		pars = parser.NewParserWithoutHeader(contentReader, &shard)
+		pars = parser.NewParserWithoutHeader(buffered, data)
 	}
+	skipped := 0
 	for {
 		eof, err := pars.Next()
 		if eof {
 			break
 		}
 		if err != nil {
-			fmt.Println(err)
+			if source.err != nil {
+				return source.err
+			}
+			log.Debug("skipped line: " + err.Error())
+			skipped++
 			continue //ignoring if any missing information in shards line
 		}
-		cluster.Add(shard)
+		add()
 	}
-	return
+	if skipped > 0 {
+		log.WithFields(log.Fields{
+			"skipped": skipped,
+		}).Warn("WARN: skipped lines with missing information")
+	}
+	return source.err
+}
+
+// sourceReader keeps the error of the reader, the parser can't tell it from a malformed line.
+type sourceReader struct {
+	reader io.Reader
+	err    error
+}
+
+func (r *sourceReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if err != nil && err != io.EOF {
+		r.err = err
+	}
+	return n, err
+}
+
+func (config *ShardRecommendationRequest) getIndexClassifier() (*models.IndexClassifier, error) {
+	classifier := models.NewIndexClassifier()
+	if len(config.SystemIndexPatterns) > 0 {
+		classifier.SystemPatterns = config.SystemIndexPatterns
+	}
+	policies := map[models.IndexClass]string{
+		models.SystemIndex:     config.SystemIndexPolicy,
+		models.HiddenIndex:     config.HiddenIndexPolicy,
+		models.DataStreamIndex: config.DataStreamIndexPolicy,
+	}
+	for class, policy := range policies {
+		if err := classifier.SetPolicy(class, policy); err != nil {
+			return nil, err
+		}
+	}
+	return classifier, nil
 }
 
 func validateInput(shards string) (valid bool) {
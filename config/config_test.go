@@ -0,0 +1,169 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"shardanalyzer/models"
+	"strings"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+const catShards = `index        shard prirep state      docs  store ip        node
+logs-000001  0     p      STARTED    1000  40gb  10.0.0.1  node-1
+logs-000001  1     p      STARTED    1000  40gb  10.0.0.2  node-2
+logs-000002  0     p      STARTED    1000  40gb  10.0.0.1  node-1
+logs-000002  1     p      STARTED    1000  40gb  10.0.0.2  node-2
+logs-000003  0     p      STARTED    10    1gb   10.0.0.1  node-1
+logs-000003  0     r      UNASSIGNED
+`
+
+const catAliases = `alias index       filter routing.index routing.search is_write_index
+logs  logs-000002 -      -             -              false
+logs  logs-000003 -      -             -              true
+`
+
+func Test_parseStatsGroupsByRolloverAlias(t *testing.T) {
+	args := ShardRecommendationRequest{
+		CatShards:         catShards,
+		CatAliases:        catAliases,
+		TargetShardSizeGB: 30,
+		NumberOfAzs:       1,
+	}
+	cluster, err := args.ParseStats()
+	assert.NoError(t, err)
+	assert.Len(t, cluster.Rollup, 1)
+	rollup := cluster.Rollup["logs"]
+	assert.Len(t, rollup.Indices, 3)
+	assert.Equal(t, "logs-000003", rollup.GetWriteIndex())
+	// the unassigned replica must not inherit the node of the previous line
+	assert.Len(t, cluster.Nodes, 2)
+	assert.Equal(t, 0, cluster.Nodes["node-1"].ReplicaShardsCount)
+
+	reco := cluster.PrepareRecommendation()
+	ipr := reco.IndexPatternRecommendationRollup[0]
+	assert.Equal(t, "logs", ipr.Alias)
+	assert.True(t, ipr.NeedChanges)
+	assert.Contains(t, ipr.GetIndexTemplateCommand(), `"logs-*"`)
+
+	// the write index right after a rollover is empty and still written to
+	args.CatShards = strings.Replace(catShards, "logs-000003  0     p      STARTED    10    1gb", "logs-000003  0     p      STARTED    0     208b", 1)
+	args.CleanupMaxDocs, args.DeductCleanup = 100, true
+	cluster, err = args.ParseStats()
+	assert.NoError(t, err)
+	reco = cluster.PrepareRecommendation()
+	assert.Empty(t, reco.CleanupIndices)
+	assert.Empty(t, reco.EmptyIndices)
+	assert.Empty(t, reco.GetCleanupCommands(0))
+	ipr = reco.IndexPatternRecommendationRollup[0]
+	if assert.Len(t, ipr.Indices, 3) {
+		assert.Equal(t, "logs-000003", ipr.Indices[2].Name)
+		assert.True(t, ipr.Indices[2].IsWriteIndex)
+	}
+}
+
+const catSegments = `index       shard prirep ip       segment generation docs.count docs.deleted size  size.memory committed searchable version compound
+logs-000001 0     p      10.0.0.1 _0      0          500        0            20gb  1024        true      true       9.7.0   false
+logs-000001 0     p      10.0.0.1 _1      1          500        500          20gb  1024        true      true       9.7.0   false
+logs-000001 0     r      10.0.0.2 _0      0          500        0            20gb  1024        true      true       9.7.0   false
+logs-000003 0     p      10.0.0.1 _2      2          5          0            1mb   1024        false     true       9.7.0   true
+logs-000003 0     p      10.0.0.1 _3      3          5          0            1mb   1024        false     true       9.7.0   true
+`
+
+func Test_parseStatsWithSegments(t *testing.T) {
+	args := ShardRecommendationRequest{
+		CatShards:         catShards,
+		CatAliases:        catAliases,
+		CatSegments:       catSegments,
+		TargetShardSizeGB: 30,
+		NumberOfAzs:       1,
+	}
+	cluster, err := args.ParseStats()
+	assert.NoError(t, err)
+
+	reco := cluster.PrepareRecommendation()
+	assert.Len(t, reco.SegmentRecommendations, 2)
+	rolled := reco.SegmentRecommendations[0]
+	assert.Equal(t, "logs-000001", rolled.Name)
+	assert.Equal(t, 2, rolled.Segments)
+	assert.InDelta(t, 0.333, rolled.DeletedDocsRatio, 0.001)
+	assert.True(t, rolled.NeedsForceMerge)
+	// the write index keeps growing, it is not merged
+	writeIndex := reco.SegmentRecommendations[1]
+	assert.Equal(t, 2, writeIndex.TinyUncommittedSegments)
+	assert.False(t, writeIndex.NeedsForceMerge)
+	assert.Equal(t, []string{"POST /logs-000001/_forcemerge?max_num_segments=1"}, reco.GetForceMergeCommands())
+}
+
+const catIndices = `health status index       uuid                   pri rep docs.count docs.deleted store.size pri.store.size
+green  open   logs-000001 6QzJ0rXxSbKZ4CqJQvV1Kg 2   0   1000       1000         80gb       80gb
+green  open   logs-000002 a1AKXyRkTT6Wb3RDYSVbtw 2   0   2000       10           80gb       80gb
+       close  archive     Jd3D2lqHRbq9V8Yg3xLhAg
+`
+
+func Test_parseStatsWithIndices(t *testing.T) {
+	args := ShardRecommendationRequest{
+		CatShards:         catShards,
+		CatIndices:        catIndices,
+		TargetShardSizeGB: 30,
+		NumberOfAzs:       1,
+	}
+	cluster, err := args.ParseStats()
+	assert.NoError(t, err)
+
+	reco := cluster.PrepareRecommendation()
+	assert.Len(t, reco.BloatedIndices, 1)
+	bloat := reco.BloatedIndices[0]
+	assert.Equal(t, "logs-000001", bloat.Name)
+	assert.Equal(t, int64(40*1024*1024*1024), bloat.ReclaimableBytes)
+	assert.Equal(t, "POST _reindex\n{\"source\": {\"index\": \"logs-000001\"}, \"dest\": {\"index\": \"logs-000001-reindexed\"}}", bloat.Command)
+	assert.Equal(t, []models.CleanupIndex{{Name: "archive", Closed: true}}, reco.CleanupIndices)
+
+	for _, ipr := range reco.IndexPatternRecommendationRollup {
+		for _, ir := range ipr.Indices {
+			if ir.Name == "logs-000001" {
+				// only the 40gb of live docs are sized, which fits in the current 2 shards
+				assert.Equal(t, 2, ir.PotentialPrimaries)
+			}
+		}
+	}
+}
+
+const catNodes = `name   ip        node.role
+node-1 10.0.0.1  dir
+node-2 10.0.0.2  dir
+node-3 10.0.0.3  dir
+master 10.0.0.4  mr`
+
+func Test_parseStatsWithNodes(t *testing.T) {
+	args := ShardRecommendationRequest{
+		CatShards:         catShards,
+		CatNodes:          catNodes,
+		TargetShardSizeGB: 30,
+		NumberOfAzs:       1,
+	}
+	cluster, err := args.ParseStats()
+	assert.NoError(t, err)
+	// node-3 holds no shards and the master holds no data
+	assert.Len(t, cluster.Nodes, 3)
+	assert.Equal(t, 0, cluster.Nodes["node-3"].PrimaryShardsCount)
+}
+
+func Test_parseReaderCountsSkippedLines(t *testing.T) {
+	var logged bytes.Buffer
+	log.SetOutput(&logged)
+	defer log.SetOutput(os.Stderr)
+
+	var shards []string
+	shard := models.ShardStats{}
+	input := catShards + "logs-000004  x     p      STARTED    10    1gb   10.0.0.1  node-1\n" +
+		"logs-000004  0     p      STARTED    many  1gb   10.0.0.1  node-1\n"
+	assert.NoError(t, parseReader(strings.NewReader(input), "index ", &shard, func() {
+		shards = append(shards, shard.Index)
+	}))
+	assert.NotContains(t, shards, "logs-000004")
+	assert.Equal(t, 1, strings.Count(logged.String(), "level=warning"), logged.String())
+	assert.Contains(t, logged.String(), "skipped=2")
+}
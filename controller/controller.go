@@ -1,28 +1,43 @@
 package controller
 
 import (
-	"fmt"
+	"context"
+	"encoding/base64"
+	"github.com/aws/aws-lambda-go/events"
 	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
 	"net/http"
-	"shardanalyzer/config"
+	"shardanalyzer/analyzer"
+	_ "shardanalyzer/docs"
 	"shardanalyzer/reports"
 	"strconv"
 )
 
-func SetupRouter() *gin.Engine {
+// HandlerFunc answers requests the way the Lambda does behind API Gateway.
+type HandlerFunc func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error)
+
+// SetupRouter serves the analyses of the service, and the requests of the Lambda with its handler.
+func SetupRouter(service *analyzer.Service, handler HandlerFunc) *gin.Engine {
 	r := gin.New()
 	r.Use(gin.Recovery())
 
+	// the JSON requests and async jobs of the Lambda
+	r.POST("/", proxy(handler))
+	r.GET("/jobs/:id", proxy(handler))
+
 	// Simple group: v1
 	v1 := r.Group("/v1")
 	{
 		shardAnalyzerGroup := v1.Group("/shard-analyzer")
 		{
-			shardAnalyzerGroup.POST("", recommend)
+			shardAnalyzerGroup.POST("", recommend(service))
 		}
 
 	}
 
+	r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+
 	return r
 }
 
@@ -30,69 +45,188 @@ func SetupRouter() *gin.Engine {
 // @Description Endpoint to take cat/shards output and examine they are rightly sharded or not and recommend the right ones if necessary.
 // @Id shardAnalyzerPost
 // @Accept application/text
-// @Produce application/json, application/pdf
+// @Produce application/json,application/pdf,text/plain,text/markdown,text/html,text/csv,application/vnd.openxmlformats-officedocument.spreadsheetml.sheet
 // @Param clusterName query string true "Cluster Name" default(Cluster-name)
-// @Param customerName query string true "Customer Name" default(AWS Customer)
-// @Param targetShardSize query int true "Target Shard Size in GB" default(30)
-// @Param azs query int true "Number of Azs for the cluster" default(3)
+// @Param customerName query string false "Customer Name" default(AWS Customer)
+// @Param targetShardSize query int false "Target Shard Size in GB, 40 for log analytics and 20 for search workloads when empty"
+// @Param targetDocsPerShard query int false "Target docs per shard, shards are sized for whichever of size and docs needs more of them"
+// @Param azs query int true "Number of Azs for the cluster, 1 to 3" default(3)
 // @Param isSearchWorkload query bool false "If log analytics, 1 replica is recommended. If not the replica count will be retained" default(false)
+// @Param Accept header string false "application/json, application/pdf, text/plain for the console table, text/markdown, text/html, text/csv of the indices or the XLSX workbook of indices, nodes and findings" default(application/json)
+// @Param locale query string false "Language of the PDF, text, Markdown and HTML reports, en or de, the Accept-Language header when empty"
+// @Param Accept-Language header string false "Language of the reports when the locale is empty" default(en)
 // @Param query body string true "Output of cat/shards."
-// @Success 400 {string} string
-// @Failure 500 {string} string
+// @Success 200 {string} string "Recommendation as JSON or report in the accepted format"
+// @Failure 400 {object} analyzer.ErrorResponse "Invalid request, with every invalid query parameter in the fields"
+// @Failure 406 {object} analyzer.ErrorResponse
+// @Failure 500 {object} analyzer.ErrorResponse
 // @Router /v1/shard-analyzer [post]
-func recommend(context *gin.Context) {
-	body, err := context.GetRawData()
-	if err != nil {
-		context.String(http.StatusBadRequest, "Error in getting request body")
-		return
+func recommend(service *analyzer.Service) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		contentType, ok := reports.NegotiateContentType(context.GetHeader("Accept"))
+		if !ok {
+			writeError(context, &analyzer.Error{Status: http.StatusNotAcceptable, Message: "Accept must allow application/json, application/pdf, text/plain, text/markdown, text/html, text/csv or " + reports.ContentTypeXLSX})
+			return
+		}
+		body, err := context.GetRawData()
+		if err != nil {
+			writeError(context, &analyzer.Error{Status: http.StatusBadRequest, Message: "Error in getting request body", Err: err})
+			return
+		}
+		query := queryParser{context: context}
+		request := analyzer.Request{
+			CatShards:         string(body),
+			TargetSize:        int(query.int("targetShardSize")),
+			TargetDocs:        query.int("targetDocsPerShard"),
+			AvailabilityZones: int(query.int("azs")),
+			Search:            query.bool("isSearchWorkload"),
+			ClusterName:       context.Query("clusterName"),
+			ClientName:        context.Query("customerName"),
+			Locale:            reports.MatchLocale(context.Query("locale"), context.GetHeader("Accept-Language")).String(),
+		}
+		// All above parses through post request fills inputs with what was passed in
+
+		if len(query.fields) > 0 {
+			// the parameters that parsed may be invalid too
+			if err, ok := request.WithDefaults().Validate().(*analyzer.Error); ok {
+				query.addFields(err.Fields)
+			}
+			writeError(context, &analyzer.Error{Status: http.StatusBadRequest, Message: "invalid request", Fields: query.fields})
+			return
+		}
+		result, err := service.Analyze(context.Request.Context(), request, contentType)
+		if err != nil {
+			writeError(context, err)
+			return
+		}
+		headers := result.Headers()
+		for name, value := range headers {
+			context.Header(name, value)
+		}
+		context.Data(http.StatusOK, headers["Content-Type"], result.Body)
 	}
-	targetstr, _ := context.GetQuery("targetShardSize")
-	targetSize, err := strconv.Atoi(targetstr)
+}
+
+// query parameters of the request fields, the others keep the names of the Lambda
+var queryNames = map[string]string{
+	"clustername":       "clusterName",
+	"clientname":        "customerName",
+	"targetsize":        "targetShardSize",
+	"targetdocs":        "targetDocsPerShard",
+	"availabilityzones": "azs",
+	"search":            "isSearchWorkload",
+	"rawInput":          "body",
+}
+
+// queryParser parses the query parameters, the ones that don't parse are collected as invalid fields
+type queryParser struct {
+	context *gin.Context
+	fields  []analyzer.FieldError
+}
+
+func (q *queryParser) int(name string) int64 {
+	value := q.context.Query(name)
+	if value == "" {
+		return 0
+	}
+	i, err := strconv.ParseInt(value, 10, 64)
 	if err != nil {
-		context.String(http.StatusBadRequest, "targetShardSize must be an integer")
-		return
+		q.fields = append(q.fields, analyzer.FieldError{Field: name, Message: "must be an integer"})
 	}
-	azstr, _ := context.GetQuery("azs")
-	numOfAzs, err := strconv.Atoi(azstr)
-	if err != nil || numOfAzs > 3 || numOfAzs < 1 {
-		context.String(http.StatusBadRequest, "azs must be an integer between 1 to 3")
-		return
+	return i
+}
+
+func (q *queryParser) bool(name string) bool {
+	value := q.context.Query(name)
+	if value == "" {
+		return false
 	}
-	isSearchStr, _ := context.GetQuery("isSearchWorkload")
-	isSearch, err := strconv.ParseBool(isSearchStr)
+	b, err := strconv.ParseBool(value)
 	if err != nil {
-		context.String(http.StatusBadRequest, "isLogAnalytics must be an either true or false")
-		return
+		q.fields = append(q.fields, analyzer.FieldError{Field: name, Message: "must be either true or false"})
+	}
+	return b
+}
+
+// addFields adds the invalid request fields under their query names, unless the parameter didn't parse
+func (q *queryParser) addFields(fields []analyzer.FieldError) {
+	for _, field := range queryFields(fields) {
+		if !q.has(field.Field) {
+			q.fields = append(q.fields, field)
+		}
 	}
-	clusterName, _ := context.GetQuery("clusterName")
-																		// All above parses through post request fills inputs with what was passed in 
-
-	args := config.ShardRecommendationRequest{							// Create Struct of all the user inputs
-		CatShards:         string(body),
-		TargetShardSizeGB: targetSize,
-		NumberOfAzs:       numOfAzs,
-		IsSearchWorkload:  isSearch,
-		ClusterName:       clusterName,
+}
+
+func (q *queryParser) has(name string) bool {
+	for _, field := range q.fields {
+		if field.Field == name {
+			return true
+		}
 	}
-	cluster, err := args.ParseStats()									// Parse through inputs given
-	if err != nil {
-		context.JSON(http.StatusBadRequest, err)
-		fmt.Println("Can't proceed. Error: ", err)
-		return
+	return false
+}
+
+func queryFields(fields []analyzer.FieldError) []analyzer.FieldError {
+	renamed := make([]analyzer.FieldError, len(fields))
+	for i, field := range fields {
+		renamed[i] = field
+		if name, ok := queryNames[field.Field]; ok {
+			renamed[i].Field = name
+		}
 	}
-	println("total shards", len(cluster.Rollup))
+	return renamed
+}
 
-	recommendation := cluster.PrepareRecommendation()					// Generate JSON of recommendation
+// writeError answers the error in the JSON envelope of the Lambda, with the fields named like the query parameters
+func writeError(context *gin.Context, err error) {
+	response := analyzer.NewErrorResponse(err)
+	response.Error.Fields = queryFields(response.Error.Fields)
+	context.JSON(response.Error.Status, response)
+}
 
-	reports.MergeSingleIndexPatterns(&recommendation)					// Not sure what this does
-	//tableString := reports.RenderAsTable(recommendation)
+// proxy passes the request on to the handler as API Gateway would
+func proxy(handler HandlerFunc) gin.HandlerFunc {
+	return func(context *gin.Context) {
+		body, err := context.GetRawData()
+		if err != nil {
+			writeError(context, &analyzer.Error{Status: http.StatusBadRequest, Message: "Error in getting request body", Err: err})
+			return
+		}
+		response, err := handler(context.Request.Context(), events.APIGatewayProxyRequest{
+			HTTPMethod: context.Request.Method,
+			Path:       context.Request.URL.Path,
+			Headers:    getHeaders(context.Request),
+			Body:       string(body),
+		})
+		writeResponse(context, response, err)
+	}
+}
+
+func getHeaders(request *http.Request) map[string]string {
+	headers := map[string]string{}
+	for name := range request.Header {
+		headers[name] = request.Header.Get(name)
+	}
+	return headers
+}
 
-	//buf, err:=reports.GeneratePDFResponse(recommendation)
-	//if err != nil {
-	//	context.String(http.StatusInternalServerError, "error while creating PDF report")
-	//} else {
-	//	context.Writer.Header().Set("Content-type", "application/pdf")
-	//	context.Writer.Write(buf.Bytes())
-	//}
-	context.JSON(http.StatusOK, recommendation)							// Not sure what this does
+// writeResponse writes the response of the handler, bodies API Gateway gets base64 encoded are decoded
+func writeResponse(context *gin.Context, response events.APIGatewayProxyResponse, err error) {
+	body := []byte(response.Body)
+	if err == nil && response.IsBase64Encoded {
+		body, err = base64.StdEncoding.DecodeString(response.Body)
+	}
+	if err != nil {
+		writeError(context, &analyzer.Error{Status: http.StatusInternalServerError, Message: "error while creating the report", Err: err})
+		return
+	}
+	contentType := reports.ContentTypeJSON // of the error envelopes
+	for name, value := range response.Headers {
+		if name == "Content-Type" {
+			contentType = value
+		} else {
+			context.Header(name, value)
+		}
+	}
+	context.Data(response.StatusCode, contentType, body)
 }
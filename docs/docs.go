@@ -32,7 +32,12 @@ var doc = `{
                 ],
                 "produces": [
                     "application/json",
-                    " application/pdf"
+                    "application/pdf",
+                    "text/plain",
+                    "text/markdown",
+                    "text/html",
+                    "text/csv",
+                    "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
                 ],
                 "summary": "Recommend shard strategies",
                 "operationId": "shardAnalyzerPost",
@@ -50,21 +55,24 @@ var doc = `{
                         "default": "AWS Customer",
                         "description": "Customer Name",
                         "name": "customerName",
-                        "in": "query",
-                        "required": true
+                        "in": "query"
                     },
                     {
                         "type": "integer",
-                        "default": 30,
-                        "description": "Target Shard Size in GB",
+                        "description": "Target Shard Size in GB, 40 for log analytics and 20 for search workloads when empty",
                         "name": "targetShardSize",
-                        "in": "query",
-                        "required": true
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Target docs per shard, shards are sized for whichever of size and docs needs more of them",
+                        "name": "targetDocsPerShard",
+                        "in": "query"
                     },
                     {
                         "type": "integer",
                         "default": 3,
-                        "description": "Number of Azs for the cluster",
+                        "description": "Number of Azs for the cluster, 1 to 3",
                         "name": "azs",
                         "in": "query",
                         "required": true
@@ -76,6 +84,26 @@ var doc = `{
                         "name": "isSearchWorkload",
                         "in": "query"
                     },
+                    {
+                        "type": "string",
+                        "default": "application/json",
+                        "description": "application/json, application/pdf, text/plain for the console table, text/markdown, text/html, text/csv of the indices or the XLSX workbook of indices, nodes and findings",
+                        "name": "Accept",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Language of the PDF, text, Markdown and HTML reports, en or de, the Accept-Language header when empty",
+                        "name": "locale",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "default": "en",
+                        "description": "Language of the reports when the locale is empty",
+                        "name": "Accept-Language",
+                        "in": "header"
+                    },
                     {
                         "description": "Output of cat/shards.",
                         "name": "query",
@@ -87,21 +115,71 @@ var doc = `{
                     }
                 ],
                 "responses": {
-                    "400": {
-                        "description": "Bad Request",
+                    "200": {
+                        "description": "Recommendation as JSON or report in the accepted format",
                         "schema": {
                             "type": "string"
                         }
                     },
+                    "400": {
+                        "description": "Invalid request, with every invalid query parameter in the fields",
+                        "schema": {
+                            "$ref": "#/definitions/analyzer.ErrorResponse"
+                        }
+                    },
+                    "406": {
+                        "description": "Not Acceptable",
+                        "schema": {
+                            "$ref": "#/definitions/analyzer.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "string"
+                            "$ref": "#/definitions/analyzer.ErrorResponse"
                         }
                     }
                 }
             }
         }
+    },
+    "definitions": {
+        "analyzer.ErrorDetails": {
+            "type": "object",
+            "properties": {
+                "fields": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/analyzer.FieldError"
+                    }
+                },
+                "message": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "integer"
+                }
+            }
+        },
+        "analyzer.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "$ref": "#/definitions/analyzer.ErrorDetails"
+                }
+            }
+        },
+        "analyzer.FieldError": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                }
+            }
+        }
     }
 }`
 
@@ -0,0 +1,98 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/lambda"
+	lambdatypes "github.com/aws/aws-sdk-go-v2/service/lambda/types"
+)
+
+// resources of the job routes, RunResource is only reached by the self-invoke
+const (
+	JobResource = "/jobs/{id}"
+	RunResource = "/jobs/{id}/run"
+)
+
+type HandlerFunc func(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error)
+
+// RunRequest is the synthetic request that runs a job.
+func RunRequest(id string) events.APIGatewayProxyRequest {
+	return events.APIGatewayProxyRequest{
+		Resource:       RunResource,
+		Path:           "/jobs/" + id + "/run",
+		HTTPMethod:     "POST",
+		PathParameters: map[string]string{"id": id},
+	}
+}
+
+// GetRunID returns the job of a run request. Requests through API Gateway always carry an API id,
+// so callers can't run jobs themselves.
+func GetRunID(request events.APIGatewayProxyRequest) (string, bool) {
+	if request.Resource != RunResource || request.RequestContext.APIID != "" {
+		return "", false
+	}
+	id, ok := request.PathParameters["id"]
+	return id, ok
+}
+
+// GetJobID returns the job of a GET /jobs/{id} request, with or without a proxy resource.
+func GetJobID(request events.APIGatewayProxyRequest) (string, bool) {
+	if request.HTTPMethod != "GET" {
+		return "", false
+	}
+	if id, ok := request.PathParameters["id"]; ok && request.Resource == JobResource {
+		return id, true
+	}
+	id := strings.TrimPrefix(request.Path, "/jobs/")
+	if id == request.Path || id == "" || strings.Contains(id, "/") {
+		return "", false
+	}
+	return id, true
+}
+
+type lambdaAPI interface {
+	Invoke(ctx context.Context, params *lambda.InvokeInput, optFns ...func(*lambda.Options)) (*lambda.InvokeOutput, error)
+}
+
+// LambdaInvoker invokes the function asynchronously with the run request of the job, the run has
+// the timeout of the function instead of the 29 seconds of API Gateway.
+type LambdaInvoker struct {
+	client       lambdaAPI
+	FunctionName string
+}
+
+func NewLambdaInvoker(cfg aws.Config, functionName string) *LambdaInvoker {
+	return &LambdaInvoker{client: lambda.NewFromConfig(cfg), FunctionName: functionName}
+}
+
+func (l *LambdaInvoker) Invoke(ctx context.Context, id string) error {
+	payload, err := json.Marshal(RunRequest(id))
+	if err != nil {
+		return err
+	}
+	_, err = l.client.Invoke(ctx, &lambda.InvokeInput{
+		FunctionName:   aws.String(l.FunctionName),
+		InvocationType: lambdatypes.InvocationTypeEvent,
+		Payload:        payload,
+	})
+	if err != nil {
+		return fmt.Errorf("invoking %s: %w", l.FunctionName, err)
+	}
+	return nil
+}
+
+// LocalInvoker runs the job in a goroutine of the same process, for local runs.
+type LocalInvoker struct {
+	Handler HandlerFunc
+}
+
+func (l LocalInvoker) Invoke(ctx context.Context, id string) error {
+	// the run outlives the request that submitted it
+	go l.Handler(context.Background(), RunRequest(id))
+	return nil
+}
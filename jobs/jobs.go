@@ -0,0 +1,204 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+type Status string
+
+const (
+	Pending   Status = "PENDING"
+	Running   Status = "RUNNING"
+	Succeeded Status = "SUCCEEDED"
+	Failed    Status = "FAILED"
+)
+
+// Job is the status of an analysis running in the background.
+type Job struct {
+	ID          string     `json:"id"`
+	Status      Status     `json:"status"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	Accept      string     `json:"accept,omitempty"`      // content type requested for the result
+	Locale      string     `json:"locale,omitempty"`      // language of the report
+	StatusCode  int        `json:"status_code,omitempty"` // status the analysis answered with
+	Error       string     `json:"error,omitempty"`
+	ContentType string     `json:"content_type,omitempty"` // of the result
+}
+
+// DefaultTimeout is the longest a Lambda runs, a job running longer was dropped by its run.
+const DefaultTimeout = 15 * time.Minute
+
+var (
+	ErrNotFound  = errors.New("job not found")
+	ErrInvalidID = errors.New("invalid job id")
+	ErrStarted   = errors.New("job already started")
+	ErrExists    = errors.New("job document exists")
+)
+
+// ids are 16 random bytes in hex, checked before they become store keys
+var idRegex = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// Store keeps the documents of the jobs under keys like <id>/status.json, Get returns ErrNotFound
+// for missing keys. Create writes only keys that don't exist yet and returns ErrExists otherwise,
+// even when two writers race for the key.
+type Store interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Create(ctx context.Context, key string, data []byte, contentType string) error
+	Get(ctx context.Context, key string) ([]byte, error)
+}
+
+// Invoker starts the run of a job in the background.
+type Invoker interface {
+	Invoke(ctx context.Context, id string) error
+}
+
+type Jobs struct {
+	Store   Store
+	Invoker Invoker
+	Timeout time.Duration // running jobs older than this are reported as failed
+	now     func() time.Time
+}
+
+func New(store Store, invoker Invoker) *Jobs {
+	return &Jobs{Store: store, Invoker: invoker, Timeout: DefaultTimeout, now: time.Now}
+}
+
+// Submit stores the request of a new job and starts its run.
+func (j *Jobs) Submit(ctx context.Context, request []byte, accept, locale string) (Job, error) {
+	id, err := newID()
+	if err != nil {
+		return Job{}, err
+	}
+	if err = j.Store.Put(ctx, requestKey(id), request, "application/json"); err != nil {
+		return Job{}, fmt.Errorf("storing request of job %s: %w", id, err)
+	}
+	now := j.now().UTC()
+	job := Job{ID: id, Status: Pending, CreatedAt: now, UpdatedAt: now, Accept: accept, Locale: locale}
+	if err = j.put(ctx, job); err != nil {
+		return Job{}, err
+	}
+	if err = j.Invoker.Invoke(ctx, id); err != nil {
+		err = fmt.Errorf("starting job %s: %w", id, err)
+		job.Status, job.Error = Failed, err.Error()
+		j.put(ctx, job)
+		return job, err
+	}
+	return job, nil
+}
+
+func (j *Jobs) Get(ctx context.Context, id string) (job Job, err error) {
+	if !idRegex.MatchString(id) {
+		return job, ErrInvalidID
+	}
+	data, err := j.Store.Get(ctx, statusKey(id))
+	if err != nil {
+		return job, err
+	}
+	if err = json.Unmarshal(data, &job); err != nil {
+		return
+	}
+	// a run that ended without finishing the job, like a Lambda that timed out, leaves it running
+	if job.Status == Running && job.StartedAt != nil && j.now().Sub(*job.StartedAt) > j.Timeout {
+		job.Status, job.Error = Failed, fmt.Sprintf("the run did not finish within %s", j.Timeout)
+	}
+	return
+}
+
+// Start marks a pending job running and returns it with its request. Runs can be delivered more
+// than once, every run but the first gets ErrStarted.
+func (j *Jobs) Start(ctx context.Context, id string) (Job, []byte, error) {
+	job, err := j.Get(ctx, id)
+	if err != nil {
+		return job, nil, err
+	}
+	if job.Status != Pending {
+		return job, nil, ErrStarted
+	}
+	// runs racing past the status check are told apart by the key only one of them can create
+	err = j.Store.Create(ctx, runKey(id), []byte(j.now().UTC().Format(time.RFC3339Nano)), "text/plain")
+	if errors.Is(err, ErrExists) {
+		return job, nil, ErrStarted
+	}
+	if err != nil {
+		return job, nil, fmt.Errorf("starting job %s: %w", id, err)
+	}
+	request, err := j.Store.Get(ctx, requestKey(id))
+	if err != nil {
+		return job, nil, err
+	}
+	startedAt := j.now().UTC()
+	job.Status, job.StartedAt = Running, &startedAt
+	return job, request, j.put(ctx, job)
+}
+
+// Finish stores the response of the analysis, the job failed when the status is not 2xx.
+func (j *Jobs) Finish(ctx context.Context, id string, statusCode int, contentType string, result []byte) error {
+	job, err := j.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	job.StatusCode = statusCode
+	if statusCode < 200 || statusCode > 299 {
+		job.Status, job.Error = Failed, string(result)
+		return j.put(ctx, job)
+	}
+	if err = j.Store.Put(ctx, resultKey(id), result, contentType); err != nil {
+		return fmt.Errorf("storing result of job %s: %w", id, err)
+	}
+	job.Status, job.ContentType = Succeeded, contentType
+	return j.put(ctx, job)
+}
+
+func (j *Jobs) GetResult(ctx context.Context, id string) ([]byte, error) {
+	if !idRegex.MatchString(id) {
+		return nil, ErrInvalidID
+	}
+	return j.Store.Get(ctx, resultKey(id))
+}
+
+func (j *Jobs) put(ctx context.Context, job Job) error {
+	job.UpdatedAt = j.now().UTC()
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	if err = j.Store.Put(ctx, statusKey(job.ID), data, "application/json"); err != nil {
+		return fmt.Errorf("storing status of job %s: %w", job.ID, err)
+	}
+	return nil
+}
+
+func newID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", fmt.Errorf("creating job id: %w", err)
+	}
+	return hex.EncodeToString(id), nil
+}
+
+func statusKey(id string) string {
+	return id + "/status.json"
+}
+
+// requests are kept for the run only, they hold no passwords as async jobs take credential references
+func requestKey(id string) string {
+	return id + "/request.json"
+}
+
+// runKey is created by the run that started the job
+func runKey(id string) string {
+	return id + "/run"
+}
+
+func resultKey(id string) string {
+	return id + "/result"
+}
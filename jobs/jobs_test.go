@@ -0,0 +1,171 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingInvoker struct {
+	ids []string
+	err error
+}
+
+func (r *recordingInvoker) Invoke(ctx context.Context, id string) error {
+	r.ids = append(r.ids, id)
+	return r.err
+}
+
+func Test_jobLifecycle(t *testing.T) {
+	ctx := context.Background()
+	invoker := &recordingInvoker{}
+	jobs := New(FileStore{Dir: t.TempDir()}, invoker)
+
+	job, err := jobs.Submit(ctx, []byte(`{"rawInput": "..."}`), "application/pdf", "de")
+	assert.NoError(t, err)
+	assert.Equal(t, Pending, job.Status)
+	assert.Equal(t, []string{job.ID}, invoker.ids)
+
+	job, request, err := jobs.Start(ctx, job.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"rawInput": "..."}`, string(request))
+	assert.Equal(t, "application/pdf", job.Accept)
+	assert.Equal(t, "de", job.Locale)
+	job, _ = jobs.Get(ctx, job.ID)
+	assert.Equal(t, Running, job.Status)
+
+	assert.NoError(t, jobs.Finish(ctx, job.ID, 200, "application/pdf", []byte("%PDF-1.3")))
+	job, _ = jobs.Get(ctx, job.ID)
+	assert.Equal(t, Succeeded, job.Status)
+	assert.Equal(t, "application/pdf", job.ContentType)
+	result, err := jobs.GetResult(ctx, job.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "%PDF-1.3", string(result))
+
+	failed, _ := jobs.Submit(ctx, []byte(`{}`), "", "")
+	assert.NoError(t, jobs.Finish(ctx, failed.ID, 400, "", []byte("ERROR: Empty _cat/shards input and Domain Endpoint")))
+	failed, _ = jobs.Get(ctx, failed.ID)
+	assert.Equal(t, Failed, failed.Status)
+	assert.Equal(t, 400, failed.StatusCode)
+	assert.Contains(t, failed.Error, "Empty _cat/shards")
+	_, err = jobs.GetResult(ctx, failed.ID)
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	invoker.err = errors.New("throttled")
+	notStarted, err := jobs.Submit(ctx, []byte(`{}`), "", "")
+	assert.Error(t, err)
+	notStarted, _ = jobs.Get(ctx, notStarted.ID)
+	assert.Equal(t, Failed, notStarted.Status)
+
+	_, err = jobs.Get(ctx, "0123456789abcdef0123456789abcdef")
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = jobs.Get(ctx, "../../etc/passwd")
+	assert.ErrorIs(t, err, ErrInvalidID)
+}
+
+func Test_redeliveredRunsDontRestartTheJob(t *testing.T) {
+	ctx := context.Background()
+	store := FileStore{Dir: t.TempDir()}
+	jobs := New(store, &recordingInvoker{})
+	job, _ := jobs.Submit(ctx, []byte(`{}`), "", "")
+
+	_, _, err := jobs.Start(ctx, job.ID)
+	assert.NoError(t, err)
+	_, _, err = jobs.Start(ctx, job.ID)
+	assert.ErrorIs(t, err, ErrStarted)
+
+	// a second run that read the job before the first one marked it running
+	pending, _ := jobs.Get(ctx, job.ID)
+	pending.Status = Pending
+	assert.NoError(t, jobs.put(ctx, pending))
+	_, _, err = jobs.Start(ctx, job.ID)
+	assert.ErrorIs(t, err, ErrStarted)
+
+	assert.NoError(t, jobs.Finish(ctx, job.ID, 200, "application/json", []byte(`{}`)))
+	_, _, err = jobs.Start(ctx, job.ID)
+	assert.ErrorIs(t, err, ErrStarted)
+	job, _ = jobs.Get(ctx, job.ID)
+	assert.Equal(t, Succeeded, job.Status)
+}
+
+func Test_staleRunningJobsFail(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC)
+	jobs := New(FileStore{Dir: t.TempDir()}, &recordingInvoker{})
+	jobs.now = func() time.Time { return now }
+	job, _ := jobs.Submit(ctx, []byte(`{}`), "", "")
+	_, _, err := jobs.Start(ctx, job.ID)
+	assert.NoError(t, err)
+
+	now = now.Add(DefaultTimeout)
+	job, _ = jobs.Get(ctx, job.ID)
+	assert.Equal(t, Running, job.Status)
+
+	now = now.Add(time.Second)
+	job, _ = jobs.Get(ctx, job.ID)
+	assert.Equal(t, Failed, job.Status)
+	assert.Equal(t, "the run did not finish within 15m0s", job.Error)
+}
+
+func Test_routes(t *testing.T) {
+	run := RunRequest("abc")
+	id, ok := GetRunID(run)
+	assert.True(t, ok)
+	assert.Equal(t, "abc", id)
+	// the same request through API Gateway
+	run.RequestContext.APIID = "a1b2c3"
+	_, ok = GetRunID(run)
+	assert.False(t, ok)
+
+	id, ok = GetJobID(events.APIGatewayProxyRequest{HTTPMethod: "GET", Resource: JobResource, Path: "/prod/jobs/abc", PathParameters: map[string]string{"id": "abc"}})
+	assert.True(t, ok)
+	assert.Equal(t, "abc", id)
+	id, ok = GetJobID(events.APIGatewayProxyRequest{HTTPMethod: "GET", Path: "/jobs/abc"})
+	assert.True(t, ok)
+	assert.Equal(t, "abc", id)
+	_, ok = GetJobID(events.APIGatewayProxyRequest{HTTPMethod: "POST", Path: "/jobs/abc"})
+	assert.False(t, ok)
+	_, ok = GetJobID(events.APIGatewayProxyRequest{HTTPMethod: "GET", Path: "/v1/shard-analyzer"})
+	assert.False(t, ok)
+}
+
+type missingObjects struct{}
+
+func (missingObjects) PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	return &s3.PutObjectOutput{}, nil
+}
+
+func (missingObjects) GetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.Options)) (*s3.GetObjectOutput, error) {
+	return nil, &types.NoSuchKey{}
+}
+
+func Test_s3StoreNotFound(t *testing.T) {
+	store := &S3Store{client: missingObjects{}, Bucket: "jobs"}
+	_, err := store.Get(context.Background(), "abc/status.json")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+type existingObjects struct {
+	missingObjects
+}
+
+func (existingObjects) PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error) {
+	if aws.ToString(params.IfNoneMatch) == "*" {
+		return nil, &smithy.GenericAPIError{Code: "PreconditionFailed"}
+	}
+	return &s3.PutObjectOutput{}, nil
+}
+
+func Test_s3StoreCreateExisting(t *testing.T) {
+	store := &S3Store{client: existingObjects{}, Bucket: "jobs"}
+	assert.NoError(t, store.Put(context.Background(), "abc/run", nil, "text/plain"))
+	assert.ErrorIs(t, store.Create(context.Background(), "abc/run", nil, "text/plain"), ErrExists)
+}
@@ -1,55 +1,35 @@
 package main
 
 import (
-	"shardanalyzer/config"
+	"shardanalyzer/analyzer"
+	"shardanalyzer/jobs"
 	"shardanalyzer/reports"
-	"shardanalyzer/models"
+	"shardanalyzer/secrets"
 	
-	"github.com/aws/aws-lambda-go/lambda"																						
 	"github.com/aws/aws-lambda-go/events"
 	"encoding/json"
-	// "errors"
+	"errors"
 	
-	"net/http"
-	"io/ioutil"
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
 	
 	// "log"
 	log "github.com/sirupsen/logrus"
 )
 																												
 func main(){																													
-	lambda.Start(Handler)
+	os.Exit(runCommand(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))						// analyze, serve or lambda, the Lambda runtime passes no arguments
 }
 
 type InputEvent struct {
-	AvailabilityZones int `json:"availabilityzones"`
-	ClusterName string `json:"clustername"`
-	ClientName string `json:"clientname"`
-	Search bool `json:"search"`
-	TargetSize int `json:"targetsize"`
-	DomainEndpoint string `json:"domainendpoint"`
-	Username string `json:"username"`
-	Password string `json:"password"`
-	CatShards string `json:"rawInput"`
-}
-
-type ResponseJson struct {
-	Title                            string                       			`json:"title"`
-	ClusterName                      string                       			`json:"cluster_name"`
-	NumberOfAZs                      int                          			`json:"number_of_azs"`
-	NumberOfDataNodes                int                          			`json:"number_of_data_nodes"`
-	TotalPrimarySize                 int64                        			`json:"total_primary_size"`
-	TotalReplicaSize                 int64                        			`json:"total_replica_size"`
-	TotalShards                      int                          			`json:"total_shards"`
-	PotentialShards                  int                          			`json:"potential_shards"`
-	TotalIndices					 int						  			`json:"total_indices"`									// Index Count is from summing length of each Indices array within the IndexPatternRecommendation that is within the IndexPatternRecommendationRollup array
-	TotalIndexPatterns				 int						  			`json:"total_index_patterns"`							// number of IndexPatternRecommendation structs that have Pattern!=No Patterns
-	RecommendedShardSizeInGb         int                          			`json:"recommended_shard_size_in_gb"`
-	NeedAdjustment         			 bool                          			`json:"need_adjustment"`
-	NodeStats         			 	 []models.NodeStats           			`json:"node_stats"`
-	LargeIndices			         []models.IndexRecommendation           `json:"large_indices"`									// Array of Indices with shards over 50g
-	IndexPatternRecommendationRollup []models.IndexPatternRecommendation 	`json:"index_pattern_recommendation_rollup"`			// Array of IndexPatternRecommendation structs
-	EmptyIndices                     []string                     			`json:"empty_indices,omitempty"`						// Array of strings listing the empty indices	
+	analyzer.Request
+	Async bool `json:"async"`										// answer with a job id right away, GET /jobs/{id} returns the result
 }
 
 type logResponse struct {
@@ -89,6 +69,21 @@ type infoLog struct {
 const ERROR = "ERROR"
 const SUCCESS = "SUCCESS"
 
+var HEAD = map[string]string{
+	"Access-Control-Allow-Origin":  "*",
+	"Access-Control-Allow-Methods": "DELETE,GET,HEAD,OPTIONS,PATCH,POST,PUT",
+	"Access-Control-Allow-Headers": "X-Amz-Date,X-Api-Key,X-Amz-Security-Token,X-Requested-With,X-Auth-Token,Referer,User-Agent,Origin,Content-Type,Authorization,Accept,Access-Control-Allow-Methods,Access-Control-Allow-Origin,Access-Control-Allow-Headers",
+}
+
+// jobs of the async mode, created on first use
+var asyncJobs *jobs.Jobs
+
+// time kept back from the Lambda deadline to build the report after collecting from a domain
+const REPORT_RESERVE = 5 * time.Second
+
+// runs the analyses of the Lambda, the server and the command line
+var service = &analyzer.Service{ThemeFile: os.Getenv("REPORT_THEME_FILE"), Reserve: REPORT_RESERVE}
+
 // create just one log structure, add omit empty to all JSON fields 	
 // create string field is error, response, request 
 // just have one log struct and only send fields that are not empty
@@ -102,110 +97,249 @@ const SUCCESS = "SUCCESS"
 // status, response object, request object, error object 
 // when success fill all except error object, when have failure fill all except response object 
 
-func Handler(request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
+func Handler(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
 
 	log.SetFormatter(&log.JSONFormatter{})
 	
-	HEAD := map[string]string{
-		"Access-Control-Allow-Origin":  "*",
-        "Access-Control-Allow-Methods": "DELETE,GET,HEAD,OPTIONS,PATCH,POST,PUT",
-        "Access-Control-Allow-Headers": "X-Amz-Date,X-Api-Key,X-Amz-Security-Token,X-Requested-With,X-Auth-Token,Referer,User-Agent,Origin,Content-Type,Authorization,Accept,Access-Control-Allow-Methods,Access-Control-Allow-Origin,Access-Control-Allow-Headers",	
-    }
+	if id, ok := jobs.GetRunID(request); ok {							// self-invoke of an async job, nobody waits for the response
+		runJob(ctx, id)
+		return events.APIGatewayProxyResponse{StatusCode: 200}, nil
+	}
+	if id, ok := jobs.GetJobID(request); ok {							// GET /jobs/{id}
+		return getJob(ctx, id), nil
+	}
 
 	if request.HTTPMethod == "POST" {
 		event, handleRequestBodyError := handleRequestBody(request.Body)
 		if handleRequestBodyError != "" {
-			createLogError(handleRequestBodyError, event)
-			return events.APIGatewayProxyResponse{						// return Error respnse
-				Headers: 		HEAD,
-				Body:			handleRequestBodyError,
-				StatusCode:		400}, nil
+			return errorResponse(newError(400, handleRequestBodyError), event), nil
 		}
-		
-		catShardsOutput, shardInputError := getCatShards(event)
-		if shardInputError != "" {
-			createLogError(shardInputError, event)
-			return events.APIGatewayProxyResponse{						// return Error respnse
-				Headers: 		HEAD,
-				Body:			shardInputError,
-				StatusCode:		400}, nil
+		contentType, ok := reports.NegotiateContentType(getHeader(request, "Accept"))
+		if !ok {
+			return errorResponse(newError(406, "Accept must allow application/json, application/pdf, text/plain, text/markdown, text/html, text/csv or " + reports.ContentTypeXLSX), event), nil
 		}
-		
-		args := config.ShardRecommendationRequest{						// Create struct of all input info
-			CatShards:         catShardsOutput,
-			TargetShardSizeGB: event.TargetSize,
-			NumberOfAzs:       event.AvailabilityZones,
-			IsSearchWorkload:  event.Search,
-			ClusterName:       event.ClientName,
-			ClientName:        event.ClusterName,						// For some Reason cluster name and client name need to be switched?
+		event.Locale = reports.MatchLocale(event.Locale, getHeader(request, "Accept-Language")).String()
+		if event.Async {
+			return submitJob(ctx, event, request.Body, contentType), nil
 		}
+		return analyze(ctx, event, contentType), nil
 		
-		cluster, err := args.ParseStats()								// parses cat/shards input and validates
+	} else{
+		notPostError := newError(400, "made a non-POST request")
+		log.WithFields(log.Fields{
+			"details": redactRequest(request),
+		}).Error("ERROR: " + notPostError.Error())
+		return jsonErrorResponse(notPostError), nil
+	}
+}
+
+// analyze answers the event with the recommendation for its cluster as JSON or a report
+func analyze(ctx context.Context, event InputEvent, contentType string) events.APIGatewayProxyResponse {
+	result, err := service.Analyze(ctx, event.Request, contentType)
+	if err != nil {
+		return errorResponse(err, event)
+	}
+	createLogResponse(event, result.Response)
+	response := events.APIGatewayProxyResponse{
+		Headers: 		HEAD,
+		Body:			string(result.Body),
+		StatusCode:		200}
+	for name, value := range result.Headers() {
+		response.Headers = withHeaders(response.Headers, name, value)
+	}
+	if reports.IsBinary(result.ContentType) {							// API Gateway decodes it when the type is one of its binary media types
+		response.Body = base64.StdEncoding.EncodeToString(result.Body)
+		response.IsBase64Encoded = true
+	}
+	return response
+}
+
+// withHeaders copies headers with the given name value pairs added, HEAD is shared by all responses
+func withHeaders(headers map[string]string, pairs ...string) map[string]string {
+	result := make(map[string]string, len(headers) + len(pairs)/2)
+	for name, value := range headers {
+		result[name] = value
+	}
+	for i := 0; i + 1 < len(pairs); i += 2 {
+		result[pairs[i]] = pairs[i+1]
+	}
+	return result
+}
+
+// getHeader returns the header whatever its case, API Gateway keeps the case the client sent
+func getHeader(request events.APIGatewayProxyRequest, name string) string {
+	for key, value := range request.Headers {
+		if strings.EqualFold(key, name) {
+			return value
+		}
+	}
+	for key, values := range request.MultiValueHeaders {
+		if strings.EqualFold(key, name) && len(values) > 0 {
+			return strings.Join(values, ",")
+		}
+	}
+	return ""
+}
+
+// getJobs uses S3 and self-invokes in a Lambda, a directory and goroutines in local runs
+func getJobs(ctx context.Context) (*jobs.Jobs, error) {
+	if asyncJobs != nil {
+		return asyncJobs, nil
+	}
+	bucket := os.Getenv("JOB_STORE_BUCKET")
+	functionName := os.Getenv("AWS_LAMBDA_FUNCTION_NAME")				// set by the Lambda runtime
+	if bucket == "" && functionName != "" {
+		return nil, errors.New("async jobs need JOB_STORE_BUCKET, Lambda instances share no file system")
+	}
+	dir := os.Getenv("JOB_STORE_DIR")
+	if dir == "" {
+		dir = filepath.Join(os.TempDir(), "shard-analyzer-jobs")
+	}
+	var store jobs.Store = jobs.FileStore{Dir: dir}
+	var invoker jobs.Invoker = jobs.LocalInvoker{Handler: Handler}
+	if bucket != "" || functionName != "" {
+		cfg, err := awsconfig.LoadDefaultConfig(ctx)
 		if err != nil {
-			parseError := "ERROR: error occured in parsing stats"
-			createLogError(parseError, event)
-			return events.APIGatewayProxyResponse{						// return events.APIGatewayProxyResponse
-				Headers: 		HEAD,
-				Body:			parseError,
-				StatusCode:		400}, nil
+			return nil, err
 		}
-		println("total shards", len(cluster.Rollup))
-		recommendation := cluster.PrepareRecommendation()				// recommendation is JSON struct that is outputted
-		reports.MergeSingleIndexPatterns(&recommendation)
-		
-		var nodeArray []models.NodeStats
-		for _, ns := range cluster.Nodes {								// copy nodes from map into an array
-			nodeArray = append(nodeArray, *ns)
+		if bucket != "" {
+			store = jobs.NewS3Store(cfg, bucket, os.Getenv("JOB_STORE_PREFIX"))
 		}
-		
-		_, indicesGreaterFifty := recommendation.GetIndicesWithLargerShards(50)
-		
-		finalResponse := ResponseJson{
-			Title: 								recommendation.Title,
-			ClusterName:						recommendation.ClusterName,
-			NumberOfAZs:						recommendation.NumberOfAZs,
-			NumberOfDataNodes:					recommendation.NumberOfDataNodes,
-			TotalPrimarySize:					recommendation.TotalPrimarySize,
-			TotalReplicaSize:					recommendation.TotalReplicaSize,
-			TotalShards:						recommendation.TotalShards,
-			PotentialShards:					recommendation.PotentialShards,
-			TotalIndices:						recommendation.GetIndexCount(),
-			TotalIndexPatterns:					recommendation.GetTotalIndexPatterns(),
-			RecommendedShardSizeInGb:			recommendation.RecommendedShardSizeInGb,
-			LargeIndices:						indicesGreaterFifty,
-			NeedAdjustment:						recommendation.NeedsShardAdjustment(),
-			NodeStats:							nodeArray,
-			IndexPatternRecommendationRollup:	recommendation.IndexPatternRecommendationRollup,
-			EmptyIndices:						recommendation.EmptyIndices,
+		if functionName != "" {
+			invoker = jobs.NewLambdaInvoker(cfg, functionName)
 		}
-		
-		
-		bodyBytes, err := json.Marshal(finalResponse)
-		if err != nil {
-			marshalError := "ERROR: error occured in json.Marshal of the final response"
-			createLogError(marshalError, event)
-			return events.APIGatewayProxyResponse{						// return events.APIGatewayProxyResponse
-				Headers: 		HEAD,
-				Body:			marshalError,
-				StatusCode:		400}, nil
+	}
+	asyncJobs = jobs.New(store, invoker)
+	return asyncJobs, nil
+}
+
+// submitJob stores the request and starts its analysis in the background
+func submitJob(ctx context.Context, event InputEvent, body string, contentType string) events.APIGatewayProxyResponse {
+	var fields []analyzer.FieldError									// invalid requests fail now and not in the job
+	if err, ok := event.Request.WithDefaults().Validate().(*analyzer.Error); ok {
+		fields = err.Fields
+	}
+	if event.Password != "" {											// requests are stored until they run
+		fields = append(fields, analyzer.FieldError{Field: "password", Message: "can't be sent with async jobs, use a credentialref"})
+	}
+	if len(fields) > 0 {
+		return errorResponse(&analyzer.Error{Status: 400, Message: "invalid request", Fields: fields}, event)
+	}
+	asyncJobs, err := getJobs(ctx)
+	if err != nil {
+		return errorResponse(err, event)
+	}
+	job, err := asyncJobs.Submit(ctx, []byte(body), contentType, event.Locale)
+	if err != nil {
+		return errorResponse(&analyzer.Error{Status: 500, Message: "error occured in submitting the job", Err: err}, event)
+	}
+	bodyBytes, _ := json.Marshal(job)
+	return events.APIGatewayProxyResponse{								// 202, the result is fetched with GET /jobs/{id}
+		Headers: 		HEAD,
+		Body:			string(bodyBytes),
+		StatusCode:		202}
+}
+
+// runJob analyzes the stored request of the job and stores the response as its result
+func runJob(ctx context.Context, id string) {
+	asyncJobs, err := getJobs(ctx)
+	if err == nil {
+		var job jobs.Job
+		var request []byte
+		if job, request, err = asyncJobs.Start(ctx, id); err == nil {
+			var response events.APIGatewayProxyResponse
+			event, handleRequestBodyError := handleRequestBody(string(request))
+			if handleRequestBodyError != "" {
+				response = errorResponse(newError(400, handleRequestBodyError), event)
+			} else {
+				event.Locale = job.Locale										// the body may lack the locale of the Accept-Language header
+				response = analyze(ctx, event, job.Accept)
+			}
+			contentType, result := getResult(response)
+			err = asyncJobs.Finish(ctx, id, response.StatusCode, contentType, result)
 		}
-		
-		createLogResponse(event, finalResponse)
-		return events.APIGatewayProxyResponse{							// return events.APIGatewayProxyResponse
-			Headers: 		HEAD,
-			Body:			string(bodyBytes),
-			StatusCode:		200}, nil
-		
-	} else{
-		notPostError := "ERROR: made a non-POST request"
+	}
+	if errors.Is(err, jobs.ErrStarted) {									// a redelivered run, the first one owns the job
 		log.WithFields(log.Fields{
-			"details": request,
-		}).Error(notPostError)
-		return events.APIGatewayProxyResponse{							// return events.APIGatewayProxyResponse
-			Headers: 		HEAD,
-			Body:			notPostError,
-			StatusCode:		400}, nil
+			"job": id,
+		}).Warn("WARN: dropped a run of a started job")
+	} else if err != nil {
+		log.WithFields(log.Fields{
+			"job": id,
+		}).Error("ERROR: error occured in running the job: " + err.Error())
+	}
+}
+
+func getResult(response events.APIGatewayProxyResponse) (string, []byte) {
+	contentType := response.Headers["Content-Type"]
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	if response.IsBase64Encoded {
+		if result, err := base64.StdEncoding.DecodeString(response.Body); err == nil {
+			return contentType, result
+		}
+	}
+	return contentType, []byte(response.Body)
+}
+
+type jobResponse struct {
+	jobs.Job
+	Result json.RawMessage `json:"result,omitempty"`						// JSON results as they are, others as base64 strings
+}
+
+// getJob answers with the status of the job and its result once it succeeded
+func getJob(ctx context.Context, id string) events.APIGatewayProxyResponse {
+	asyncJobs, err := getJobs(ctx)
+	var response jobResponse
+	if err == nil {
+		response.Job, err = asyncJobs.Get(ctx, id)
+	}
+	if err == nil && response.Status == jobs.Succeeded {
+		var result []byte
+		if result, err = asyncJobs.GetResult(ctx, id); err == nil {
+			if strings.HasPrefix(response.ContentType, "application/json") {
+				response.Result = result
+			} else {
+				response.Result, _ = json.Marshal(base64.StdEncoding.EncodeToString(result))
+			}
+		}
+	}
+	if err != nil {
+		status := 500
+		if errors.Is(err, jobs.ErrNotFound) {
+			status = 404
+		} else if errors.Is(err, jobs.ErrInvalidID) {
+			status = 400
+		}
+		jobError := &analyzer.Error{Status: status, Message: "error occured in getting job " + id, Err: err}
+		log.Error("ERROR: " + jobError.Error())
+		return jsonErrorResponse(jobError)
 	}
+	bodyBytes, _ := json.Marshal(response)
+	return events.APIGatewayProxyResponse{
+		Headers: 		HEAD,
+		Body:			string(bodyBytes),
+		StatusCode:		200}
+}
+
+func errorResponse(err error, event InputEvent) events.APIGatewayProxyResponse {
+	createLogError("ERROR: " + err.Error(), event)
+	return jsonErrorResponse(err)
+}
+
+// jsonErrorResponse answers the error in the JSON envelope all transports share
+func jsonErrorResponse(err error) events.APIGatewayProxyResponse {
+	envelope := analyzer.NewErrorResponse(err)
+	bodyBytes, _ := json.Marshal(envelope)
+	return events.APIGatewayProxyResponse{								// return Error respnse
+		Headers: 		withHeaders(HEAD, "Content-Type", reports.ContentTypeJSON),
+		Body:			string(bodyBytes),
+		StatusCode:		envelope.Error.Status}
+}
+
+func newError(status int, message string) error {
+	return &analyzer.Error{Status: status, Message: message}
 }
 
 func createLogError(errorMessage string, event InputEvent){
@@ -225,72 +359,31 @@ func createLogError(errorMessage string, event InputEvent){
 	}).Error(errorMessage)
 }
 
+// redactRequest copies the request without the credentials of its body, headers and identity, for logging
+func redactRequest(request events.APIGatewayProxyRequest) events.APIGatewayProxyRequest {
+	request.Body = secrets.RedactJSON(request.Body)
+	request.Headers = secrets.RedactHeaders(request.Headers)
+	request.MultiValueHeaders = secrets.RedactMultiValueHeaders(request.MultiValueHeaders)
+	if request.RequestContext.Identity.AccessKey != "" {
+		request.RequestContext.Identity.AccessKey = secrets.Redacted
+	}
+	if request.RequestContext.Identity.APIKey != "" {
+		request.RequestContext.Identity.APIKey = secrets.Redacted
+	}
+	return request
+}
+
 func handleRequestBody(requestBody string)(InputEvent, string){
 	event:=InputEvent{}
 	err := json.Unmarshal([]byte(requestBody), &event) 					// change request body into InputEvent struct
 	if err != nil {
-		return InputEvent{}, "ERROR: error occured in json.Unmarshal of request Body"
+		return InputEvent{}, "error occured in json.Unmarshal of request Body"
 	}
 	
 	return event, ""
 }
 
-func getCatShards(event InputEvent)(string, string) {
-	if(event.DomainEndpoint != ""){										// first check if Domain endpoint is not empty, then use GET request for _cat/shards
-		getRequestReturn, err := getURL(event.DomainEndpoint, event.Username, event.Password)
-		if err != ""{													// check if error occured
-			return "", err												// if error occured pass along error message
-		} else{
-			return getRequestReturn, ""									// if no error occured pass along GET request return
-		}
-	} else if(event.CatShards != ""){									// if domain endpoint is empty check if cat/shards is empty
-		return event.CatShards, ""
-	} else {															// if both are empty then return an error message
-		return "", "ERROR: Empty _cat/shards input and Domain Endpoint"
-	}
-}
-
-func getURL(url string, username string, password string) (string, string) {
-	client := &http.Client{}
-	
-	// check if there is / at end
-	if(string(url[len(url)-1])=="/"){
-		url=url+"_cat/shards?v"
-	} else{
-		url = url+"/_cat/shards?v"
-	}
-	// create new request
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil{
-		return "", "ERROR: error occured in creating Request"
-		// RETURN ERROR for creating request
-	}
-	// set authentication of new request
-	if(username != "" && password != ""){								// if one of the fields is empty, don't set basic authentication
-		req.SetBasicAuth(username, password)
-	}
-	// send request
-	resp, err := client.Do(req)
-	if err != nil{
-		return "", "ERROR: error occured in sending GET request to the DomainEndpoint"
-		// RETURN ERROR for sending request
-	}
-	bodyText, err := ioutil.ReadAll(resp.Body)
-    s := string(bodyText)
-	
-	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
-        return s, ""
-    } else {
-        return "", "ERROR: " + s
-    }
-	// resp.getStatusCode != HTTP.OKstatus
-	// then generate error response
-	// whether 200 or error, read the response body 
-	// if its an error, send the response body as an error message 
-	// not an error then proceed as normal with response body
-}
-
-func createLogResponse(event InputEvent, response ResponseJson) {
+func createLogResponse(event InputEvent, response analyzer.Response) {
 	
 	successStruct:= infoLog{
 				Status: SUCCESS,
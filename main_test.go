@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"shardanalyzer/controller"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+// a daily pattern with too many shards, an index with a shard over 50 GB and an empty index
+var catShards = readTestdata("cat_shards_mixed.txt")
+
+// readTestdata reads a fixture of the testdata directory the packages share
+func readTestdata(name string) string {
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		panic(err)
+	}
+	return string(data)
+}
+
+// the request of the fields to the Lambda and the route of the server for the Lambda, and of the query to its
+// /v1/shard-analyzer route
+func getTransportResponses(t *testing.T, accept string, fields map[string]interface{}, query url.Values) (lambda events.APIGatewayProxyResponse, proxy, server *httptest.ResponseRecorder) {
+	log.SetOutput(io.Discard)
+	gin.SetMode(gin.TestMode)
+	service.Now = func() time.Time { return time.Date(2024, 1, 31, 23, 59, 59, 0, time.UTC) }
+	body, err := json.Marshal(fields)
+	assert.NoError(t, err)
+	lambda, err = Handler(context.Background(), events.APIGatewayProxyRequest{HTTPMethod: "POST", Headers: map[string]string{"accept": accept}, Body: string(body)})
+	assert.NoError(t, err)
+
+	router := controller.SetupRouter(service, Handler)
+	request := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(string(body)))
+	request.Header.Set("Accept", accept)
+	proxy = httptest.NewRecorder()
+	router.ServeHTTP(proxy, request)
+
+	request = httptest.NewRequest(http.MethodPost, "/v1/shard-analyzer?"+query.Encode(), strings.NewReader(catShards))
+	request.Header.Set("Accept", accept)
+	server = httptest.NewRecorder()
+	router.ServeHTTP(server, request)
+	return
+}
+
+// the same analysis for all transports
+func getValidResponses(t *testing.T, accept string) (lambda events.APIGatewayProxyResponse, proxy, server *httptest.ResponseRecorder) {
+	var fields map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(readTestdata("request.json")), &fields))
+	fields["rawInput"] = catShards
+	return getTransportResponses(t, accept, fields, url.Values{"clusterName": {"prod"}, "customerName": {"acme"}, "targetShardSize": {"30"}, "azs": {"2"}, "isSearchWorkload": {"false"}})
+}
+
+func Test_transportsAnswerTheSameJSON(t *testing.T) {
+	lambda, proxy, server := getValidResponses(t, "application/json")
+	assert.Equal(t, 200, lambda.StatusCode)
+	assert.Equal(t, 200, server.Code, server.Body.String())
+	assert.Equal(t, lambda.Body, proxy.Body.String())
+	assert.Equal(t, lambda.Body, server.Body.String())
+
+	var response map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(lambda.Body), &response))
+	assert.Equal(t, "prod", response["cluster_name"])
+	assert.Equal(t, "acme", response["title"])
+	assert.Equal(t, true, response["need_adjustment"])
+	assert.Len(t, response["large_indices"], 1)
+}
+
+func Test_transportsAnswerTheSameReport(t *testing.T) {
+	lambda, proxy, server := getValidResponses(t, "text/markdown")
+	assert.Equal(t, "text/markdown; charset=utf-8", lambda.Headers["Content-Type"])
+	assert.Equal(t, lambda.Headers["Content-Type"], server.Header().Get("Content-Type"))
+	assert.Equal(t, lambda.Body, proxy.Body.String())
+	assert.Equal(t, lambda.Body, server.Body.String())
+	assert.Contains(t, lambda.Body, "Wed Jan 31, 2024")
+}
+
+func Test_transportsAnswerTheSameErrors(t *testing.T) {
+	lambda, proxy, server := getTransportResponses(t, "application/json",
+		map[string]interface{}{"rawInput": catShards, "availabilityzones": 5},
+		url.Values{"azs": {"5"}})
+	assert.Equal(t, 400, lambda.StatusCode)
+	assert.Equal(t, "application/json", lambda.Headers["Content-Type"])
+	assert.Equal(t, lambda.Body, proxy.Body.String())
+	assert.JSONEq(t, `{"error": {"status": 400, "message": "invalid request", "fields": [
+		{"field": "clustername", "message": "is required"}, {"field": "availabilityzones", "message": "must be between 1 and 3"}]}}`, lambda.Body)
+	// the same fields under the names of the query parameters
+	assert.Equal(t, 400, server.Code)
+	assert.JSONEq(t, `{"error": {"status": 400, "message": "invalid request", "fields": [
+		{"field": "clusterName", "message": "is required"}, {"field": "azs", "message": "must be between 1 and 3"}]}}`, server.Body.String())
+
+	// parameters that don't parse are listed with the invalid ones
+	_, _, server = getTransportResponses(t, "application/json", map[string]interface{}{},
+		url.Values{"clusterName": {"prod"}, "azs": {"two"}, "targetShardSize": {"-5"}})
+	assert.JSONEq(t, `{"error": {"status": 400, "message": "invalid request", "fields": [
+		{"field": "azs", "message": "must be an integer"}, {"field": "targetShardSize", "message": "must be at least 1"}]}}`, server.Body.String())
+
+	lambda, proxy, server = getValidResponses(t, "image/png")
+	assert.Equal(t, 406, lambda.StatusCode)
+	assert.Equal(t, lambda.Body, proxy.Body.String())
+	assert.Equal(t, lambda.Body, server.Body.String())
+}
+
+func Test_asyncRequestsAreValidatedBeforeSubmit(t *testing.T) {
+	log.SetOutput(io.Discard)
+	dir := t.TempDir()
+	t.Setenv("JOB_STORE_DIR", dir)
+	invalid := map[string]interface{}{"rawInput": catShards, "availabilityzones": 5}
+	body, err := json.Marshal(invalid)
+	assert.NoError(t, err)
+	sync, err := Handler(context.Background(), events.APIGatewayProxyRequest{HTTPMethod: "POST", Body: string(body)})
+	assert.NoError(t, err)
+
+	invalid["async"] = true
+	body, err = json.Marshal(invalid)
+	assert.NoError(t, err)
+	async, err := Handler(context.Background(), events.APIGatewayProxyRequest{HTTPMethod: "POST", Body: string(body)})
+	assert.NoError(t, err)
+	assert.Equal(t, 400, async.StatusCode)
+	assert.Equal(t, sync.Body, async.Body)
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Empty(t, entries, "no job is stored")
+
+	// the password is listed with the other invalid fields
+	invalid["password"] = "secret"
+	body, err = json.Marshal(invalid)
+	assert.NoError(t, err)
+	async, err = Handler(context.Background(), events.APIGatewayProxyRequest{HTTPMethod: "POST", Body: string(body)})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"error": {"status": 400, "message": "invalid request", "fields": [
+		{"field": "clustername", "message": "is required"}, {"field": "availabilityzones", "message": "must be between 1 and 3"},
+		{"field": "password", "message": "can't be sent with async jobs, use a credentialref"}]}}`, async.Body)
+}
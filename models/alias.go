@@ -0,0 +1,100 @@
+package models
+
+import (
+	"regexp"
+	"sort"
+)
+
+// rollover indices end with a generation number, e.g. logs-000001
+var rolloverIndexRegex = regexp.MustCompile(`-\d+$`)
+
+type RolloverAlias struct {
+	Name       string   `json:"name"`
+	Indices    []string `json:"indices"`
+	WriteIndex string   `json:"write_index"`
+}
+
+func (c *Cluster) AddAlias(as AliasStats) {
+	if c.Aliases == nil {
+		c.Aliases = map[string]*RolloverAlias{}
+	}
+	alias := c.Aliases[as.Alias]
+	if alias == nil {
+		alias = &RolloverAlias{Name: as.Alias}
+		c.Aliases[as.Alias] = alias
+	}
+	if as.isWriteIndex() {
+		alias.WriteIndex = as.Index
+	}
+	alias.Indices = append(alias.Indices, as.Index)
+	sort.Strings(alias.Indices)
+	c.rolloverAliases = nil // resolved again with the next lookup
+}
+
+// isRollover reports whether the alias is used for rollover: it has an explicit write index,
+// or it points to a single generation numbered index as the legacy rollover aliases do.
+func (ra *RolloverAlias) isRollover() bool {
+	if ra.WriteIndex != "" {
+		return true
+	}
+	return len(ra.Indices) == 1 && rolloverIndexRegex.MatchString(ra.Indices[0])
+}
+
+func (ra *RolloverAlias) getWriteIndex() string {
+	if ra.WriteIndex == "" && len(ra.Indices) == 1 {
+		return ra.Indices[0]
+	}
+	return ra.WriteIndex
+}
+
+// resolveRolloverAliases maps the indices, and the name pattern of each write index, to their rollover alias.
+// Indices rolled over by a legacy alias are no longer part of it, they are found by the name pattern.
+func (c *Cluster) resolveRolloverAliases() {
+	c.rolloverAliases = map[string]*RolloverAlias{}
+	var names []string
+	for name, alias := range c.Aliases {
+		if alias.isRollover() {
+			names = append(names, name)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(names))) // the first alias in name order wins
+	for _, name := range names {
+		alias := c.Aliases[name]
+		for _, index := range alias.Indices {
+			c.rolloverAliases[index] = alias
+		}
+		c.rolloverAliases[GetIndexPattern(alias.getWriteIndex())] = alias
+	}
+	for _, name := range names {
+		// an index is kept with the alias it is the write index of
+		alias := c.Aliases[name]
+		c.rolloverAliases[alias.getWriteIndex()] = alias
+	}
+}
+
+func (c *Cluster) getRolloverAlias(index string) *RolloverAlias {
+	if len(c.Aliases) == 0 {
+		return nil
+	}
+	if c.rolloverAliases == nil {
+		c.resolveRolloverAliases()
+	}
+	if alias := c.rolloverAliases[index]; alias != nil {
+		return alias
+	}
+	return c.rolloverAliases[GetIndexPattern(index)]
+}
+
+func (c *Cluster) GetRolloverAliases() (aliases []RolloverAlias) {
+	for _, alias := range c.Aliases {
+		if alias.isRollover() {
+			a := *alias
+			a.WriteIndex = alias.getWriteIndex()
+			aliases = append(aliases, a)
+		}
+	}
+	sort.Slice(aliases, func(i, j int) bool {
+		return aliases[i].Name < aliases[j].Name
+	})
+	return
+}
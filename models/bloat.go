@@ -0,0 +1,102 @@
+package models
+
+import "sort"
+
+const (
+	BloatDeletedRatio        = 0.1               // deleted docs ratio from which an index counts as bloated
+	BloatMinReclaimableBytes = 100 * 1024 * 1024 // smaller savings are not worth a merge
+	ReindexDeletedRatio      = 0.5               // from here on a reindex is cheaper than expunging
+)
+
+type IndexBloat struct {
+	Name             string  `json:"name"`
+	Pattern          string  `json:"pattern"`
+	Docs             int64   `json:"docs"`
+	DeletedDocs      int64   `json:"deleted_docs"`
+	DeletedDocsRatio float64 `json:"deleted_docs_ratio"`
+	BytesPerDoc      float64 `json:"bytes_per_doc"`
+	ReclaimableBytes int64   `json:"reclaimable_bytes"`
+	Remediation      string  `json:"remediation"`
+	Command          string  `json:"command"`
+}
+
+// AddIndexStats adds a line of _cat/indices to its index, the shards have to be added first. Closed
+// indices without any listed shard are kept aside for the cleanup report.
+func (c *Cluster) AddIndexStats(is IndexStats) {
+	is.normalize()
+	ir := c.indices[is.Index]
+	if ir == nil {
+		if is.isClosed() {
+			c.ClosedIndices = append(c.ClosedIndices, is)
+		}
+		return
+	}
+	ir.Stats = &is
+}
+
+func (ir *IndexRollup) getDeletedDocsRatio() float64 {
+	if ir.Stats == nil || ir.Stats.DocCount+ir.Stats.DeletedDocCount == 0 {
+		return 0
+	}
+	return float64(ir.Stats.DeletedDocCount) / float64(ir.Stats.DocCount+ir.Stats.DeletedDocCount)
+}
+
+// GetReclaimableBytes estimates the primary store taken by deleted docs.
+func (ir *IndexRollup) GetReclaimableBytes() int64 {
+	return int64(float64(ir.PrimarySizeBytes) * ir.getDeletedDocsRatio())
+}
+
+func (ir *IndexRollup) getBloat(pattern string) *IndexBloat {
+	if ir.Stats == nil {
+		return nil
+	}
+	ib := &IndexBloat{
+		Name:             ir.IndexName,
+		Pattern:          pattern,
+		Docs:             ir.Stats.DocCount,
+		DeletedDocs:      ir.Stats.DeletedDocCount,
+		DeletedDocsRatio: ir.getDeletedDocsRatio(),
+		ReclaimableBytes: ir.GetReclaimableBytes(),
+	}
+	if ib.Docs > 0 {
+		ib.BytesPerDoc = float64(ir.PrimarySizeBytes) / float64(ib.Docs)
+	}
+	if ib.DeletedDocsRatio < BloatDeletedRatio || ib.ReclaimableBytes < BloatMinReclaimableBytes {
+		return nil
+	}
+	if ib.DeletedDocsRatio >= ReindexDeletedRatio {
+		ib.Remediation = "Reindex into a new index, most of the index is made of deleted docs"
+		ib.Command = "POST _reindex\n{\"source\": {\"index\": \"" + ir.IndexName + "\"}, \"dest\": {\"index\": \"" + ir.IndexName + "-reindexed\"}}"
+	} else {
+		ib.Remediation = "Expunge the deleted docs with a forcemerge"
+		ib.Command = "POST /" + ir.IndexName + "/_forcemerge?only_expunge_deletes=true"
+	}
+	return ib
+}
+
+// addClosedIndices lists the closed indices only known from _cat/indices for cleanup. They have no
+// shards allocated, so the shard counts are left untouched.
+func (r *Recommendation) addClosedIndices(closed []IndexStats, classifier *IndexClassifier) {
+	for i := range closed {
+		r.AddCleanupIndex(&IndexRollup{
+			IndexName: closed[i].Index,
+			Class:     classifier.Classify(closed[i].Index),
+			Stats:     &closed[i],
+		})
+	}
+}
+
+func (ipr *IndexPatternRecommendation) setBloatRatios() {
+	if ipr.Docs+ipr.DeletedDocs > 0 {
+		ipr.DeletedDocsRatio = float64(ipr.DeletedDocs) / float64(ipr.Docs+ipr.DeletedDocs)
+	}
+	if ipr.Docs > 0 {
+		ipr.BytesPerDoc = float64(ipr.Size) / float64(ipr.Docs)
+	}
+}
+
+func sortIndexBloat(bloat []IndexBloat) {
+	sort.Slice(bloat, func(i, j int) bool {
+		return bloat[i].ReclaimableBytes > bloat[j].ReclaimableBytes
+	})
+}
@@ -1,20 +1,32 @@
 package models
 
 import (
-	"log"
-	"regexp"
+	"strings"
 )
 
+// IndexStats is a line of _cat/indices, fields are in the column order of the API.
 type IndexStats struct {
 	Health          string `json:"health" tsv:"health"`
 	Status          string `json:"status" tsv:"status"`
 	Index           string `json:"index" tsv:"index"`
+	UUID            string `json:"uuid" tsv:"uuid"`
 	NoOfShards      int    `json:"no_of_shards" tsv:"pri"`
 	NoOfReplica     int    `json:"no_of_replica" tsv:"rep"`
-	DocCount        int    `json:"doc_count" tsv:"docs.count"`
-	DeletedDocCount int    `json:"deleted_doc_count" tsv:"docs.deleted"`
-	StorageSize     int    `json:"storage_size" tsv:"store.size"`
-	PriStorageSize  int    `json:"pri_storage_size" tsv:"pri.store.size"`
+	DocCount        int64  `json:"doc_count" tsv:"docs.count"`
+	DeletedDocCount int64  `json:"deleted_doc_count" tsv:"docs.deleted"`
+	StorageSize     int64  `json:"storage_size" tsv:"store.size"`
+	PriStorageSize  int64  `json:"pri_storage_size" tsv:"pri.store.size"`
+}
+
+// normalize shifts the columns of closed indices, their health column is blank and gets lost when splitting the line.
+func (is *IndexStats) normalize() {
+	if is.Health == "open" || is.Health == "close" {
+		is.Health, is.Status, is.Index, is.UUID = "", is.Health, is.Status, is.Index
+	}
+}
+
+func (is *IndexStats) isClosed() bool {
+	return is.Status == "close"
 }
 
 type ShardStats struct {
@@ -36,28 +48,40 @@ func (ss *ShardStats) isPrimary() (primary bool) {
 }
 
 func (ss *ShardStats) getIndexPattern() (pattern string) {
-	reg, err := regexp.Compile("[0-9]")
-	if err != nil {
-		log.Fatal(err)
-	}
-	pattern = reg.ReplaceAllString(ss.Index, "*")
-	return
+	return GetIndexPattern(ss.Index)
 }
 
+// GetIndexPattern replaces the digits of the index name, indices rolled by date or number share a pattern.
+func GetIndexPattern(index string) string {
+	// runs for every shard line, a regexp is several times slower
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return '*'
+		}
+		return r
+	}, index)
+}
+
+// AllocationStats is a line of _cat/segments, fields are in the column order of the API.
 type AllocationStats struct {
-	Index       string `json:"index"`
-	Shard       int    `json:"shard"`
-	Type        string `json:"type"`
-	IpAddress   string `json:"ip_address"`
-	Segment     string `json:"segment"`
-	Generation  int    `json:"generation"`
-	Docs        int    `json:"docs"`
-	DeletedDocs int    `json:"deleted_docs"`
-	Size        int    `json:"size"`
-	Searchable  bool   `json:"searchable"`
-	Committed   bool   `json:"committed"`
-	Version     string `json:"version"`
-	Compound    string `json:"compound"`
+	Index       string `json:"index" tsv:"index"`
+	Shard       int    `json:"shard" tsv:"shard"`
+	Type        string `json:"type" tsv:"prirep"`
+	IpAddress   string `json:"ip_address" tsv:"ip"`
+	Segment     string `json:"segment" tsv:"segment"`
+	Generation  int    `json:"generation" tsv:"generation"`
+	Docs        int    `json:"docs" tsv:"docs.count"`
+	DeletedDocs int    `json:"deleted_docs" tsv:"docs.deleted"`
+	Size        int64  `json:"size" tsv:"size"`
+	SizeMemory  int64  `json:"size_memory" tsv:"size.memory"`
+	Committed   bool   `json:"committed" tsv:"committed"`
+	Searchable  bool   `json:"searchable" tsv:"searchable"`
+	Version     string `json:"version" tsv:"version"`
+	Compound    string `json:"compound" tsv:"compound"`
+}
+
+func (as *AllocationStats) isPrimary() bool {
+	return as.Type == "p"
 }
 
 type NodeStats struct {
@@ -78,6 +102,18 @@ func (node *NodeStats) adjustStats(status ShardStats) {
 	}
 }
 
+// NodeInfo is a line of _cat/nodes?h=name,ip,node.role.
+type NodeInfo struct {
+	Name      string `json:"name" tsv:"name"`
+	IpAddress string `json:"ip_address" tsv:"ip"`
+	Role      string `json:"role" tsv:"node.role"`
+}
+
+// isDataNode is true for any of the data roles: data, content, hot, warm, cold and frozen.
+func (ni *NodeInfo) isDataNode() bool {
+	return strings.ContainsAny(ni.Role, "dshwcf")
+}
+
 type IndexNodeStats struct {
 	Primaries int
 	Replicas  int
@@ -98,3 +134,16 @@ func (in *IndexNodeStats) adjustShardCountFor(status ShardStats) {
 		in.increaseReplica()
 	}
 }
+
+type AliasStats struct {
+	Alias         string `json:"alias" tsv:"alias"`
+	Index         string `json:"index" tsv:"index"`
+	Filter        string `json:"filter" tsv:"filter"`
+	RoutingIndex  string `json:"routing_index" tsv:"routing.index"`
+	RoutingSearch string `json:"routing_search" tsv:"routing.search"`
+	IsWriteIndex  string `json:"is_write_index" tsv:"is_write_index"` // true, false or - when not set
+}
+
+func (as *AliasStats) isWriteIndex() bool {
+	return as.IsWriteIndex == "true"
+}
@@ -0,0 +1,130 @@
+package models
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+type IndexClass string
+
+const (
+	DataIndex       IndexClass = "data"
+	SystemIndex     IndexClass = "system"
+	HiddenIndex     IndexClass = "hidden"
+	DataStreamIndex IndexClass = "data_stream"
+)
+
+type IndexPolicy string
+
+const (
+	PolicyExclude IndexPolicy = "exclude" // ignored entirely, shards are carried over as they are
+	PolicyReport  IndexPolicy = "report"  // listed with their footprint, but not analyzed
+	PolicyAnalyze IndexPolicy = "analyze" // analyzed and recommended like user data
+)
+
+const DataStreamBackingPrefix = ".ds-"
+
+var DefaultSystemIndexPatterns = []string{
+	".kibana*",
+	".opendistro*",
+	".plugins*",
+	".opensearch*",
+	".security*",
+	".tasks",
+	".ql-datasources",
+}
+
+type IndexClassifier struct {
+	SystemPatterns []string
+	Policies       map[IndexClass]IndexPolicy
+}
+
+func NewIndexClassifier() *IndexClassifier {
+	return &IndexClassifier{
+		SystemPatterns: DefaultSystemIndexPatterns,
+		Policies: map[IndexClass]IndexPolicy{
+			DataIndex:       PolicyAnalyze,
+			SystemIndex:     PolicyReport,
+			HiddenIndex:     PolicyReport,
+			DataStreamIndex: PolicyAnalyze,
+		},
+	}
+}
+
+// Classify places the index in one of the index classes. Data stream backing indices
+// are checked first as they are dot-prefixed too.
+func (ic *IndexClassifier) Classify(name string) IndexClass {
+	if strings.HasPrefix(name, DataStreamBackingPrefix) {
+		return DataStreamIndex
+	}
+	for _, pattern := range ic.SystemPatterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return SystemIndex
+		}
+	}
+	if strings.HasPrefix(name, ".") {
+		return HiddenIndex
+	}
+	return DataIndex
+}
+
+func (ic *IndexClassifier) PolicyFor(class IndexClass) IndexPolicy {
+	if policy, ok := ic.Policies[class]; ok {
+		return policy
+	}
+	return PolicyAnalyze
+}
+
+// SetPolicy overrides the policy of a class, an empty policy keeps the default one.
+func (ic *IndexClassifier) SetPolicy(class IndexClass, policy string) error {
+	if policy == "" {
+		return nil
+	}
+	p, err := ParseIndexPolicy(policy)
+	if err != nil {
+		return err
+	}
+	ic.Policies[class] = p
+	return nil
+}
+
+func ParseIndexPolicy(policy string) (IndexPolicy, error) {
+	switch p := IndexPolicy(strings.ToLower(strings.TrimSpace(policy))); p {
+	case PolicyExclude, PolicyReport, PolicyAnalyze:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown index policy %q, expected one of exclude, report or analyze", policy)
+}
+
+type ClassifiedIndex struct {
+	Name               string     `json:"name"`
+	Class              IndexClass `json:"class"`
+	Docs               int64      `json:"docs"`
+	PrimarySizeInBytes int64      `json:"primary_size_in_bytes"`
+	Primaries          int        `json:"primaries"`
+	Replicas           int        `json:"replicas"`
+}
+
+// countIndexClass keeps the per class index counts of the recommendation.
+func (r *Recommendation) countIndexClass(class IndexClass) {
+	switch class {
+	case SystemIndex:
+		r.SystemIndexCount++
+	case HiddenIndex:
+		r.HiddenIndexCount++
+	case DataStreamIndex:
+		r.DataStreamIndexCount++
+	}
+}
+
+func (r *Recommendation) AddReportedIndex(ir *IndexRollup) {
+	r.ReportedIndices = append(r.ReportedIndices, ClassifiedIndex{
+		Name:               ir.IndexName,
+		Class:              ir.Class,
+		Docs:               ir.Docs,
+		PrimarySizeInBytes: ir.PrimarySizeBytes,
+		Primaries:          ir.Primaries,
+		Replicas:           ir.Replicas,
+	})
+}
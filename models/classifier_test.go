@@ -0,0 +1,33 @@
+package models
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_classifyIndices(t *testing.T) {
+	ic := NewIndexClassifier()
+	assert.Equal(t, SystemIndex, ic.Classify(".kibana_1"))
+	assert.Equal(t, SystemIndex, ic.Classify(".opendistro-job-scheduler-lock"))
+	assert.Equal(t, SystemIndex, ic.Classify(".plugins-ml-config"))
+	assert.Equal(t, DataStreamIndex, ic.Classify(".ds-logs-app-2024.01.01-000001"))
+	assert.Equal(t, HiddenIndex, ic.Classify(".my-hidden"))
+	assert.Equal(t, DataIndex, ic.Classify("logs-2024.01.01"))
+}
+
+func Test_indexPolicies(t *testing.T) {
+	c := newTestCluster(
+		ShardStats{Index: ".kibana_1", Type: "p", Docs: 10, StoreSize: 1024, Node: "n1"},
+		ShardStats{Index: ".my-hidden", Type: "p", Docs: 0, StoreSize: 208, Node: "n1"},
+		ShardStats{Index: "logs", Type: "p", Docs: 10, StoreSize: 1024, Node: "n1"},
+	)
+	assert.NoError(t, c.GetClassifier().SetPolicy(HiddenIndex, "exclude"))
+	assert.Error(t, c.GetClassifier().SetPolicy(SystemIndex, "drop"))
+	reco := c.PrepareRecommendation()
+
+	assert.Equal(t, 1, reco.SystemIndexCount)
+	assert.Equal(t, 1, reco.HiddenIndexCount)
+	assert.Equal(t, []ClassifiedIndex{{Name: ".kibana_1", Class: SystemIndex, Docs: 10, PrimarySizeInBytes: 1024, Primaries: 1}}, reco.ReportedIndices)
+	assert.Empty(t, reco.EmptyIndices)
+	assert.Equal(t, 1, reco.GetIndexCount())
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"sort"
+	"strings"
+)
+
+// DefaultCleanupBatchSize is the number of indices deleted by a single generated command.
+const DefaultCleanupBatchSize = 25
+
+type CleanupIndex struct {
+	Name               string `json:"name"`
+	Docs               int64  `json:"docs"`
+	PrimarySizeInBytes int64  `json:"primary_size_in_bytes"`
+	Primaries          int    `json:"primaries"`
+	Replicas           int    `json:"replicas"`
+	Closed             bool   `json:"closed"`
+}
+
+func (ci CleanupIndex) GetShardCount() int {
+	return ci.Primaries + ci.Replicas
+}
+
+// isNearEmpty reports whether the index holds data but stays below one of the configured cleanup thresholds.
+func (c *Cluster) isNearEmpty(ir *IndexRollup) bool {
+	if c.CleanupMaxDocs > 0 && ir.PrimaryDocs < c.CleanupMaxDocs {
+		return true
+	}
+	if c.CleanupMaxSizeBytes > 0 && ir.PrimarySizeBytes < c.CleanupMaxSizeBytes {
+		return true
+	}
+	return false
+}
+
+// AddCleanupIndex records the index and its shard footprint as a cleanup candidate.
+// It returns false when the index is not eligible for cleanup.
+func (r *Recommendation) AddCleanupIndex(ir *IndexRollup) bool {
+	if ir.Class != DataIndex {
+		return false // system, hidden and backing indices are never deleted from here
+	}
+	ci := CleanupIndex{
+		Name:               ir.IndexName,
+		Docs:               ir.PrimaryDocs,
+		PrimarySizeInBytes: ir.PrimarySizeBytes,
+		Primaries:          ir.Primaries,
+		Replicas:           ir.Replicas,
+		Closed:             ir.IsClosed(),
+	}
+	r.CleanupIndices = append(r.CleanupIndices, ci)
+	r.CleanupShards += ci.GetShardCount()
+	return true
+}
+
+// GetCleanupCommands returns DELETE commands for the cleanup indices, batchSize indices per command.
+func (r *Recommendation) GetCleanupCommands(batchSize int) (commands []string) {
+	if batchSize <= 0 {
+		batchSize = DefaultCleanupBatchSize
+	}
+	var names []string
+	for _, ci := range r.CleanupIndices {
+		names = append(names, ci.Name)
+	}
+	sort.Strings(names)
+	for start := 0; start < len(names); start += batchSize {
+		end := start + batchSize
+		if end > len(names) {
+			end = len(names)
+		}
+		commands = append(commands, "DELETE /"+strings.Join(names[start:end], ","))
+	}
+	return
+}
@@ -0,0 +1,58 @@
+package models
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func newTestCluster(shards ...ShardStats) *Cluster {
+	c := &Cluster{
+		Name:                 "test",
+		NumberOfAZs:          1,
+		RecommendedShardSize: 30,
+		Nodes:                map[string]*NodeStats{},
+		Rollup:               map[string]*IndexPatternRollup{},
+	}
+	for _, s := range shards {
+		c.Add(s)
+	}
+	return c
+}
+
+func cleanupShards() []ShardStats {
+	return []ShardStats{
+		{Index: "logs-a", Shard: 0, Type: "p", State: "STARTED", Docs: 1000000, StoreSize: 1 << 30, Node: "n1"},
+		{Index: "logs-a", Shard: 0, Type: "r", State: "STARTED", Docs: 1000000, StoreSize: 1 << 30, Node: "n2"},
+		{Index: "empty", Shard: 0, Type: "p", State: "STARTED", Docs: 0, StoreSize: 208, Node: "n1"},
+		{Index: "empty", Shard: 0, Type: "r", State: "STARTED", Docs: 0, StoreSize: 208, Node: "n2"},
+		{Index: "tiny", Shard: 0, Type: "p", State: "STARTED", Docs: 10, StoreSize: 4096, Node: "n2"},
+		{Index: ".kibana", Shard: 0, Type: "p", State: "STARTED", Docs: 0, StoreSize: 208, Node: "n1"},
+	}
+}
+
+func Test_cleanupWithoutDeduction(t *testing.T) {
+	c := newTestCluster(cleanupShards()...)
+	c.CleanupMaxDocs = 100
+	reco := c.PrepareRecommendation()
+
+	assert.Len(t, reco.CleanupIndices, 2)
+	assert.Equal(t, 3, reco.CleanupShards)
+	// logs-a and tiny get one replica each, empty and .kibana keep their current shards
+	assert.Equal(t, 7, reco.PotentialShards)
+}
+
+func Test_cleanupWithDeduction(t *testing.T) {
+	c := newTestCluster(cleanupShards()...)
+	c.CleanupMaxDocs = 100
+	c.DeductCleanupShards = true
+	reco := c.PrepareRecommendation()
+
+	assert.Equal(t, 3, reco.CleanupShards)
+	assert.Equal(t, 3, reco.PotentialShards)
+	assert.Equal(t, []string{"DELETE /empty,tiny"}, reco.GetCleanupCommands(DefaultCleanupBatchSize))
+}
+
+func Test_cleanupCommandsAreBatched(t *testing.T) {
+	reco := Recommendation{CleanupIndices: []CleanupIndex{{Name: "c"}, {Name: "a"}, {Name: "b"}}}
+	assert.Equal(t, []string{"DELETE /a,b", "DELETE /c"}, reco.GetCleanupCommands(2))
+}
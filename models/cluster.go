@@ -19,6 +19,21 @@ type Cluster struct {
 	Nodes                 map[string]*NodeStats
 	TotalPrimarySizeBytes int64
 	TotalReplicaSizeBytes int64
+	CleanupMaxDocs        int64 // indices below this doc count are reported for cleanup, 0 disables
+	CleanupMaxSizeBytes   int64 // indices below this primary size are reported for cleanup, 0 disables
+	DeductCleanupShards   bool  // drop cleanup candidates from the potential shard count
+	Classifier            *IndexClassifier
+	DataStreams           map[string]*DataStream
+	Aliases               map[string]*RolloverAlias // from _cat/aliases, add them before the shards
+	rolloverAliases       map[string]*RolloverAlias // index or index pattern to rollover alias
+	indices               map[string]*IndexRollup
+	ClosedIndices         []IndexStats // closed indices from _cat/indices that have no shards listed
+	SkewFactor            float64      // largest to median shard ratio reported as routing skew, DefaultSkewFactor when unset
+	Thresholds            FindingThresholds
+	DocLimitFraction      float64          // share of the Lucene doc limit a shard may hold, DefaultDocLimitFraction when unset
+	TargetDocsPerShard    int64            // sizes shards by doc count too when set, for workloads of tiny docs
+	Now                   func() time.Time // clock of the doc limit projections, time.Now when nil
+	SkippedInputs         []SkippedInput   // optional cat APIs the domain did not answer
 }
 
 type Recommendation struct {
@@ -31,8 +46,23 @@ type Recommendation struct {
 	TotalShards                      int                          `json:"total_shards"`
 	PotentialShards                  int                          `json:"potential_shards"`
 	RecommendedShardSizeInGb         int                          `json:"recommended_shard_size_in_gb"`
+	TargetDocsPerShard               int64                        `json:"target_docs_per_shard,omitempty"`
 	IndexPatternRecommendationRollup []IndexPatternRecommendation `json:"index_pattern_recommendation_rollup"`			// Array of IndexPatternRecommendation structs
 	EmptyIndices                     []string                     `json:"empty_indices,omitempty"`						// Array of strings listing the empty indices
+	CleanupIndices                   []CleanupIndex               `json:"cleanup_indices,omitempty"`						// Empty, near-empty and closed indices that could be deleted
+	CleanupShards                    int                          `json:"cleanup_shards"`									// Shards (primaries and replicas) held by the cleanup indices
+	SystemIndexCount                 int                          `json:"system_index_count"`
+	HiddenIndexCount                 int                          `json:"hidden_index_count"`
+	DataStreamIndexCount             int                          `json:"data_stream_index_count"`
+	ReportedIndices                  []ClassifiedIndex            `json:"reported_indices,omitempty"`						// System and hidden indices listed without analysis
+	DataStreams                      []DataStream                 `json:"data_streams,omitempty"`
+	RolloverAliases                  []RolloverAlias              `json:"rollover_aliases,omitempty"`
+	SegmentRecommendations           []IndexSegmentRecommendation `json:"segment_recommendations,omitempty"`				// Only with _cat/segments input
+	BloatedIndices                   []IndexBloat                 `json:"bloated_indices,omitempty"`						// Only with _cat/indices input
+	SkewedIndices                    []ShardSkew                  `json:"skewed_indices,omitempty"`						// Indices with shards much larger than their median shard
+	Thresholds                       FindingThresholds            `json:"thresholds"`
+	SkippedInputs                    []SkippedInput               `json:"skipped_inputs,omitempty"`						// Cat APIs the domain did not answer, the report is built without them
+	Findings                         []Finding                    `json:"findings"`										// Everything flagged by the analysis, most severe first
 }
 
 type IndexPatternRecommendation struct {
@@ -46,31 +76,57 @@ type IndexPatternRecommendation struct {
 	PotentialReplicaShards int                    `json:"potential_replica_shards"`
 	OldestIndexTime        time.Time              `json:"oldest_index_time"`
 	NewestIndexTime        time.Time              `json:"newest_index_time"`
+	Docs                   int64                  `json:"docs"`
+	DeletedDocs            int64                  `json:"deleted_docs,omitempty"`							// Only with _cat/indices input
+	DeletedDocsRatio       float64                `json:"deleted_docs_ratio,omitempty"`
+	BytesPerDoc            float64                `json:"bytes_per_doc,omitempty"`
+	ReclaimableBytes       int64                  `json:"reclaimable_bytes,omitempty"`						// Primary store taken by deleted docs, left out of the sizing
 	Message                string                 `json:"message"`
-	foundRotation          bool                   `json:"found_rotation"`
+	DataStream             string                 `json:"data_stream,omitempty"`							// Name of the data stream when the pattern groups its backing indices
+	Alias                  string                 `json:"alias,omitempty"`									// Name of the rollover alias when the pattern groups its indices
+	WriteIndex             string                 `json:"write_index,omitempty"`							// Still growing, so it is left out of the verdict and the template
+	foundRotation          bool
 }
 
 type IndexRecommendation struct {
-	Name               string `json:"name"`
-	Primaries          int    `json:"primaries"`
-	PrimarySizeInBytes int64  `json:"primary_size_in_bytes"`
-	PotentialPrimaries int    `json:"potential_primaries"`
-	Docs               int64  `json:"docs"`
-	Replicas           int    `json:"replicas"`
-	PotentialReplicas  int    `json:"potential_replicas"`
+	Name                string              `json:"name"`
+	Primaries           int                 `json:"primaries"`
+	PrimarySizeInBytes  int64               `json:"primary_size_in_bytes"`
+	PotentialPrimaries  int                 `json:"potential_primaries"`
+	Docs                int64               `json:"docs"`
+	Replicas            int                 `json:"replicas"`
+	PotentialReplicas   int                 `json:"potential_replicas"`
+	IsWriteIndex        bool                `json:"is_write_index,omitempty"`
+	DeletedDocs         int64               `json:"deleted_docs,omitempty"`
+	ReclaimableBytes    int64               `json:"reclaimable_bytes,omitempty"`
+	MaxShardSizeInBytes int64               `json:"max_shard_size_in_bytes"`
+	MinShardSizeInBytes int64               `json:"min_shard_size_in_bytes"`
+	ShardSizeStdDev     float64             `json:"shard_size_std_dev"`
+	MaxShardDocs        int64               `json:"max_shard_docs"`
+	DocLimitProjection  *DocLimitProjection `json:"doc_limit_projection,omitempty"`
+	SizingConstraint    SizingConstraint    `json:"sizing_constraint"`
 }
 
 func (c *Cluster) PrepareRecommendation() Recommendation {
+	now := time.Now
+	if c.Now != nil {
+		now = c.Now
+	}
 	reco := Recommendation{
-		Title:                            c.Name,
-		ClusterName:                      c.ClientName,
+		Title:                            c.ClientName,
+		ClusterName:                      c.Name,
 		NumberOfAZs:                      c.NumberOfAZs,
 		NumberOfDataNodes:                len(c.Nodes),
 		RecommendedShardSizeInGb:         c.RecommendedShardSize,
+		TargetDocsPerShard:               c.TargetDocsPerShard,
 		TotalPrimarySize:                 c.TotalPrimarySizeBytes,
 		TotalReplicaSize:                 c.TotalReplicaSizeBytes,
 		IndexPatternRecommendationRollup: []IndexPatternRecommendation{},
 		EmptyIndices:                     []string{},
+		CleanupIndices:                   []CleanupIndex{},
+		DataStreams:                      c.GetDataStreams(),
+		RolloverAliases:                  c.GetRolloverAliases(),
+		Thresholds:                       c.Thresholds.withDefaults(),
 	}
 	//var targetShardSizeInBytes int64
 	targetShardSizeInBytes := int64(reco.RecommendedShardSizeInGb * 1024 * 1024 * 1024)
@@ -81,8 +137,24 @@ func (c *Cluster) PrepareRecommendation() Recommendation {
 			Pattern: pattern,
 			Indices: []*IndexRecommendation{},
 		}
+		ipreco.WriteIndex = ipr.GetWriteIndex()
+		if ipr.DataStream != nil {
+			ipreco.DataStream = ipr.DataStream.Name
+			ipreco.Message = "Apply the recommendation to the index template of the data stream, backing indices pick it up on rollover"
+		} else if ipr.Alias != nil {
+			ipreco.Alias = ipr.Alias.Name
+			ipreco.Message = "Apply the recommendation to the index template of the rollover alias, the write index is left as it is"
+		}
 		for _, ir := range ipr.Indices {
 			reco.TotalShards += ir.Replicas + ir.Primaries
+			reco.countIndexClass(ir.Class)
+			if policy := c.GetClassifier().PolicyFor(ir.Class); policy != PolicyAnalyze {
+				if policy == PolicyReport {
+					reco.AddReportedIndex(ir)
+				}
+				reco.PotentialShards += ir.Primaries + ir.Replicas
+				continue
+			}
 			ireco := IndexRecommendation{
 				Name:               ir.IndexName,
 				PrimarySizeInBytes: ir.PrimarySizeBytes,
@@ -91,18 +163,68 @@ func (c *Cluster) PrepareRecommendation() Recommendation {
 				Docs:               ir.Docs,
 				PotentialReplicas:  ir.Replicas,
 				PotentialPrimaries: ir.Primaries,
+				IsWriteIndex:       ipreco.WriteIndex == ir.IndexName,
+			}
+			if sr := ipr.getSegmentRecommendation(ir); sr != nil {
+				reco.SegmentRecommendations = append(reco.SegmentRecommendations, *sr)
+			}
+			// the write index of an alias or data stream is often empty right after a rollover, it
+			// is still written to and can't be cleaned up
+			if !ireco.IsWriteIndex && (ir.IsEmpty() || ir.IsClosed()) {
+				if ir.IsEmpty() {
+					reco.AddEmptyIndex(ir)
+				}
+				if !reco.AddCleanupIndex(ir) || !c.DeductCleanupShards {
+					// the index is kept as it is, so its shards stay in the cluster
+					reco.PotentialShards += ir.Primaries + ir.Replicas
+				}
+				continue
 			}
-			if ir.IsEmpty() {
-				reco.AddEmptyIndex(ir)
+			if !ireco.IsWriteIndex && c.isNearEmpty(ir) && reco.AddCleanupIndex(ir) && c.DeductCleanupShards {
 				continue
 			}
 
 			ipreco.PrimaryShards += ir.Primaries
 			ipreco.ReplicaShards += ir.Replicas
 			ipreco.Size += ir.PrimarySizeBytes
+			ipreco.Docs += ir.PrimaryDocs
+			shardSizes := ir.getShardSizeStats()
+			ireco.MaxShardSizeInBytes = shardSizes.Max
+			ireco.MinShardSizeInBytes = shardSizes.Min
+			ireco.ShardSizeStdDev = shardSizes.StdDev
+			ireco.MaxShardDocs = shardSizes.MaxDocs
+			if skew := ir.getShardSkew(pattern, shardSizes, c.getSkewFactor()); skew != nil {
+				reco.SkewedIndices = append(reco.SkewedIndices, *skew)
+			}
+			if ir.Stats != nil {
+				ireco.DeletedDocs = ir.Stats.DeletedDocCount
+				ireco.ReclaimableBytes = ir.GetReclaimableBytes()
+				ipreco.DeletedDocs += ireco.DeletedDocs
+				ipreco.ReclaimableBytes += ireco.ReclaimableBytes
+				if ib := ir.getBloat(pattern); ib != nil {
+					reco.BloatedIndices = append(reco.BloatedIndices, *ib)
+				}
+			}
 
-			idealShardCount := sc.getIdealShardCount(ir.Primaries, ir.PrimarySizeBytes, targetShardSizeInBytes)
-			if ir.Primaries != idealShardCount && idealShardCount > 0 {
+			// deleted docs are merged away eventually, they do not count towards the shard size
+			idealShardCount := sc.getIdealShardCount(ir.Primaries, ir.PrimarySizeBytes-ireco.ReclaimableBytes, targetShardSizeInBytes)
+			ireco.SizingConstraint = SizedBySize
+			expectedDocs := ipr.getExpectedPrimaryDocs(ir)
+			if c.TargetDocsPerShard > 0 {
+				docShardCount := sc.getShardCountForDocs(ir.Primaries, expectedDocs, c.TargetDocsPerShard)
+				if docShardCount > idealShardCount {
+					idealShardCount = docShardCount
+					ireco.SizingConstraint = SizedByDocs
+				}
+			}
+			// small docs can hit the Lucene doc limit long before the target size
+			limitShardCount := sc.getShardCountForDocs(ir.Primaries, expectedDocs, c.getMaxDocsPerShard())
+			if limitShardCount > idealShardCount {
+				idealShardCount = limitShardCount
+				ireco.SizingConstraint = SizedByDocLimit
+			}
+			ireco.DocLimitProjection = ipr.getDocLimitProjection(ir, shardSizes.MaxDocs, now())
+			if ir.Primaries != idealShardCount && idealShardCount > 0 && !ireco.IsWriteIndex {
 				ipreco.NeedChanges = true
 			}
 			ireco.PotentialPrimaries = idealShardCount
@@ -121,6 +243,11 @@ func (c *Cluster) PrepareRecommendation() Recommendation {
 			}
 			ipreco.Indices = append(ipreco.Indices, &ireco)
 		}
+		if len(ipreco.Indices) == 0 {
+			continue // nothing left to recommend for this pattern
+		}
+		ipreco.setBloatRatios()
+		ipreco.OldestIndexTime, ipreco.NewestIndexTime, _ = ipreco.GetIndexDates()
 		//adjust replicas if there are potential warm indices
 		ipreco.AdjustPotentialReplicaShards()
 		//sort based in index names
@@ -129,14 +256,49 @@ func (c *Cluster) PrepareRecommendation() Recommendation {
 		})
 		reco.IndexPatternRecommendationRollup = append(reco.IndexPatternRecommendationRollup, ipreco)
 	}
+	// the patterns come from a map, sort them so the responses are the same for the same input
+	sort.Slice(reco.IndexPatternRecommendationRollup, func(i, j int) bool {
+		return reco.IndexPatternRecommendationRollup[i].Pattern < reco.IndexPatternRecommendationRollup[j].Pattern
+	})
+	reco.addClosedIndices(c.ClosedIndices, c.GetClassifier())
+	sortIndexBloat(reco.BloatedIndices)
+	sort.Slice(reco.SkewedIndices, func(i, j int) bool {
+		return reco.SkewedIndices[i].Name < reco.SkewedIndices[j].Name
+	})
+	sort.Slice(reco.CleanupIndices, func(i, j int) bool {
+		return reco.CleanupIndices[i].Name < reco.CleanupIndices[j].Name
+	})
+	sort.Slice(reco.ReportedIndices, func(i, j int) bool {
+		return reco.ReportedIndices[i].Name < reco.ReportedIndices[j].Name
+	})
+	sort.Slice(reco.SegmentRecommendations, func(i, j int) bool {
+		return reco.SegmentRecommendations[i].Name < reco.SegmentRecommendations[j].Name
+	})
+	reco.SkippedInputs = c.SkippedInputs
+	reco.BuildFindings()
 
 	return reco
 }
 
+func (c *Cluster) GetClassifier() *IndexClassifier {
+	if c.Classifier == nil {
+		c.Classifier = NewIndexClassifier()
+	}
+	return c.Classifier
+}
+
 func (c *Cluster) Add(status ShardStats) {
 	pattern := status.Index
 
-	if !c.IsSearchWorkload {
+	alias := c.getRolloverAlias(status.Index)
+	if stream, ok := c.addDataStreamIndex(status.Index); ok {
+		//backing indices are always grouped by their data stream
+		pattern = stream
+		alias = nil
+	} else if alias != nil {
+		//indices of a rollover alias are grouped by the alias
+		pattern = alias.Name
+	} else if !c.IsSearchWorkload {
 		//Don't rollup indices for search workloads
 		pattern = status.getIndexPattern()
 	}
@@ -144,30 +306,37 @@ func (c *Cluster) Add(status ShardStats) {
 	patternRollup := c.Rollup[pattern]
 	if patternRollup == nil {
 		//build one
-		patternRollup = &IndexPatternRollup{Pattern: pattern, Parent: c, Indices: map[string]*IndexRollup{}}
+		patternRollup = &IndexPatternRollup{Pattern: pattern, Parent: c, Indices: map[string]*IndexRollup{}, DataStream: c.DataStreams[pattern], Alias: alias}
 		indexRollup := NewIndexRollup(index, patternRollup)
+		indexRollup.Class = c.GetClassifier().Classify(index)
 		indexRollup.add(status)
 		patternRollup.Indices[status.Index] = indexRollup
 		c.Rollup[pattern] = patternRollup
+		c.addIndex(indexRollup)
 	} else {
 		indexRollup := patternRollup.Indices[index]
 		if indexRollup == nil {
 			//create one
 			indexRollup := NewIndexRollup(index, patternRollup)
+			indexRollup.Class = c.GetClassifier().Classify(index)
 			indexRollup.add(status)
 			patternRollup.Indices[status.Index] = indexRollup
+			c.addIndex(indexRollup)
 		} else {
 			indexRollup.add(status)
 		}
 	}
-	node := c.Nodes[status.Node]
-	if node == nil {
-		node = &NodeStats{
-			NodeName: status.Node,
+	if status.Node != "" {
+		// unassigned shards are not on any node
+		node := c.Nodes[status.Node]
+		if node == nil {
+			node = &NodeStats{
+				NodeName: status.Node,
+			}
+			c.Nodes[status.Node] = node
 		}
-		c.Nodes[status.Node] = node
+		node.adjustStats(status)
 	}
-	node.adjustStats(status)
 	if status.isPrimary() {
 		c.TotalPrimarySizeBytes += status.StoreSize
 	} else {
@@ -175,6 +344,22 @@ func (c *Cluster) Add(status ShardStats) {
 	}
 }
 
+// AddNode registers the data nodes of _cat/nodes, so nodes that hold no shards are counted too.
+func (c *Cluster) AddNode(info NodeInfo) {
+	if !info.isDataNode() || c.Nodes[info.Name] != nil {
+		return
+	}
+	c.Nodes[info.Name] = &NodeStats{NodeName: info.Name}
+}
+
+func (c *Cluster) addIndex(ir *IndexRollup) {
+	if c.indices == nil {
+		c.indices = map[string]*IndexRollup{}
+	}
+	c.indices[ir.IndexName] = ir
+	ir.Parent.dates = nil
+}
+
 func (r Recommendation) GetIndexCount() (count int) {
 	for _, ipr := range r.IndexPatternRecommendationRollup {
 		count += len(ipr.Indices)
@@ -183,10 +368,7 @@ func (r Recommendation) GetIndexCount() (count int) {
 }
 
 func (r *Recommendation) AddEmptyIndex(ir *IndexRollup) {
-	if !strings.HasPrefix(ir.IndexName, ".") {
-		r.EmptyIndices = append(r.EmptyIndices, ir.IndexName)
-		//r.PotentialShards = r.PotentialShards - ir.Primaries - ir.Replicas
-	}
+	r.EmptyIndices = append(r.EmptyIndices, ir.IndexName)
 }
 
 func (r *Recommendation) NeedsShardAdjustment() bool {
@@ -207,7 +389,7 @@ func (r *Recommendation) GetIndicesWithLargerShards(threshold int) (bool, []Inde
 			continue // as it was already processed with regular indices
 		}
 		for _, ir := range ipr.Indices {
-			if ir.PrimarySizeInBytes/(int64(ir.Primaries)) > targetShardSizeInBytes {
+			if ir.MaxShardSizeInBytes > targetShardSizeInBytes {
 				recommendation = append(recommendation, *ir)
 			}
 		}
@@ -225,8 +407,25 @@ func (r Recommendation) GetTotalIndexPatterns() (total int) {
 	return
 }
 
+// GetIndexDates finds the oldest and newest index of time based patterns to find out the retention.
 func (ipr *IndexPatternRecommendation) GetIndexDates() (oldest time.Time, newest time.Time, retentionInDays int) {
-	//TODO: Implement to find oldest and newest index to find out the retention pattern
+	var periodDays int
+	for _, ir := range ipr.Indices {
+		date, period, ok := getIndexDate(ir.Name)
+		if !ok {
+			continue
+		}
+		if oldest.IsZero() || date.Before(oldest) {
+			oldest = date
+		}
+		if date.After(newest) {
+			newest = date
+		}
+		periodDays = period
+	}
+	if !oldest.IsZero() {
+		retentionInDays = int(newest.Sub(oldest).Hours()/24) + periodDays
+	}
 	return
 }
 
@@ -279,9 +478,27 @@ type IndexTemplate struct {
 	Settings      IndexSettings `json:"settings"`
 }
 
+// ComposableIndexTemplate is the _index_template body, data streams can only be created from these.
+type ComposableIndexTemplate struct {
+	IndexPatterns []string         `json:"index_patterns"`
+	DataStream    *struct{}        `json:"data_stream,omitempty"`
+	Template      TemplateSettings `json:"template"`
+}
+
+type TemplateSettings struct {
+	Settings IndexSettings `json:"settings"`
+}
+
 func (ipr *IndexPatternRecommendation) GetIndexTemplateCommand() (cmd string) {
+	if ipr.IsDataStream() {
+		return ipr.getDataStreamTemplateCommand()
+	}
+	pattern := ipr.Pattern
+	if ipr.Alias != "" && ipr.WriteIndex != "" {
+		pattern = rolloverIndexRegex.ReplaceAllString(ipr.WriteIndex, "-*")
+	}
 	it := IndexTemplate{
-		IndexPatterns: []string{ipr.Pattern},
+		IndexPatterns: []string{pattern},
 		Settings: IndexSettings{
 			NumberOfReplicas: 1,
 			NumberOfShards:   ipr.getRecommendedPrimaryShardsCount(),
@@ -295,16 +512,38 @@ func (ipr *IndexPatternRecommendation) GetIndexTemplateCommand() (cmd string) {
 	return
 }
 
+func (ipr *IndexPatternRecommendation) getDataStreamTemplateCommand() (cmd string) {
+	it := ComposableIndexTemplate{
+		IndexPatterns: []string{ipr.DataStream + "*"},
+		DataStream:    &struct{}{},
+		Template: TemplateSettings{
+			Settings: IndexSettings{
+				NumberOfReplicas: 1,
+				NumberOfShards:   ipr.getRecommendedPrimaryShardsCount(),
+			},
+		},
+	}
+	cmdBytes, err := PrettyStruct(it)
+	if err == nil {
+		cmd = "PUT _index_template/" + ipr.DataStream + "\n"
+		return cmd + cmdBytes
+	}
+	return
+}
+
 func (ipr *IndexPatternRecommendation) getRecommendedPrimaryShardsCount() int {
 	//var avgShardRecomended int
 	dict := make(map[int]int)
 	for _, indices := range ipr.Indices {
+		if indices.IsWriteIndex && len(ipr.Indices) > 1 {
+			continue // only completed indices show the final size
+		}
 		dict[indices.PotentialPrimaries]++
 	}
 	common, shardCount := 0, 0
 	for shard, count := range dict {
-		if count > common {
-			shardCount = shard
+		if count > common || (count == common && shard > shardCount) {
+			common, shardCount = count, shard
 		}
 	}
 	//avgShardRecomended = ipr.PotentialPrimaryShards / len(ipr.Indices)
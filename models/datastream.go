@@ -0,0 +1,75 @@
+package models
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// backing indices are named .ds-<data-stream>-<yyyy.MM.dd>-<generation>, older versions omit the date
+var backingIndexRegex = regexp.MustCompile(`^\.ds-(.+?)(?:-\d{4}\.\d{2}\.\d{2})?-(\d{6,})$`)
+
+type DataStream struct {
+	Name           string   `json:"name"`
+	BackingIndices []string `json:"backing_indices"`
+	WriteIndex     string   `json:"write_index"`
+	Generation     int      `json:"generation"`
+}
+
+func parseBackingIndex(index string) (stream string, generation int, ok bool) {
+	match := backingIndexRegex.FindStringSubmatch(index)
+	if match == nil {
+		return
+	}
+	generation, err := strconv.Atoi(match[2])
+	if err != nil {
+		return
+	}
+	return match[1], generation, true
+}
+
+// addBackingIndex registers the backing index with its data stream, the highest generation is the write index.
+func (ds *DataStream) addBackingIndex(index string, generation int) {
+	for _, name := range ds.BackingIndices {
+		if name == index {
+			return
+		}
+	}
+	ds.BackingIndices = append(ds.BackingIndices, index)
+	sort.Strings(ds.BackingIndices)
+	if generation > ds.Generation {
+		ds.Generation = generation
+		ds.WriteIndex = index
+	}
+}
+
+func (c *Cluster) addDataStreamIndex(index string) (stream string, ok bool) {
+	stream, generation, ok := parseBackingIndex(index)
+	if !ok {
+		return
+	}
+	if c.DataStreams == nil {
+		c.DataStreams = map[string]*DataStream{}
+	}
+	ds := c.DataStreams[stream]
+	if ds == nil {
+		ds = &DataStream{Name: stream}
+		c.DataStreams[stream] = ds
+	}
+	ds.addBackingIndex(index, generation)
+	return
+}
+
+func (c *Cluster) GetDataStreams() (streams []DataStream) {
+	for _, ds := range c.DataStreams {
+		streams = append(streams, *ds)
+	}
+	sort.Slice(streams, func(i, j int) bool {
+		return streams[i].Name < streams[j].Name
+	})
+	return
+}
+
+func (ipr *IndexPatternRecommendation) IsDataStream() bool {
+	return ipr.DataStream != ""
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func Test_parseBackingIndex(t *testing.T) {
+	stream, generation, ok := parseBackingIndex(".ds-logs-app-2024.01.01-000001")
+	assert.True(t, ok)
+	assert.Equal(t, "logs-app", stream)
+	assert.Equal(t, 1, generation)
+
+	stream, generation, ok = parseBackingIndex(".ds-metrics-000012")
+	assert.True(t, ok)
+	assert.Equal(t, "metrics", stream)
+	assert.Equal(t, 12, generation)
+
+	_, _, ok = parseBackingIndex("logs-2024.01.01")
+	assert.False(t, ok)
+}
+
+func Test_dataStreamGrouping(t *testing.T) {
+	c := newTestCluster(
+		ShardStats{Index: ".ds-logs-app-2024.01.01-000001", Type: "p", Docs: 10, StoreSize: 1024, Node: "n1"},
+		ShardStats{Index: ".ds-logs-app-2024.01.02-000002", Type: "p", Docs: 10, StoreSize: 1024, Node: "n1"},
+		ShardStats{Index: ".ds-logs-app-2024.01.03-000003", Type: "p", Docs: 10, StoreSize: 1024, Node: "n1"},
+	)
+	assert.Len(t, c.Rollup, 1)
+	assert.Equal(t, ".ds-logs-app-2024.01.03-000003", c.DataStreams["logs-app"].WriteIndex)
+
+	reco := c.PrepareRecommendation()
+	ipr := reco.IndexPatternRecommendationRollup[0]
+	assert.Equal(t, "logs-app", ipr.DataStream)
+	assert.Equal(t, 3, reco.DataStreamIndexCount)
+	assert.True(t, ipr.Indices[2].IsWriteIndex)
+	assert.True(t, strings.HasPrefix(ipr.GetIndexTemplateCommand(), "PUT _index_template/logs-app\n"))
+}
+
+func Test_emptyDataStreamWriteIndexIsKept(t *testing.T) {
+	c := newTestCluster(
+		ShardStats{Index: ".ds-logs-app-2024.01.01-000001", Type: "p", Docs: 10, StoreSize: 1024, Node: "n1"},
+		ShardStats{Index: ".ds-logs-app-2024.01.02-000002", Type: "p", Docs: 0, StoreSize: 208, Node: "n1"},
+	)
+	c.CleanupMaxDocs, c.DeductCleanupShards = 100, true
+
+	reco := c.PrepareRecommendation()
+	// the write index of a stream can't be deleted
+	assert.Empty(t, reco.CleanupIndices)
+	assert.Empty(t, reco.EmptyIndices)
+	assert.Empty(t, reco.GetCleanupCommands(0))
+	ipr := reco.IndexPatternRecommendationRollup[0]
+	if assert.Len(t, ipr.Indices, 2) {
+		assert.True(t, ipr.Indices[1].IsWriteIndex)
+	}
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"regexp"
+	"time"
+)
+
+var (
+	dailyIndexRegex        = regexp.MustCompile(`(\d{4})[.\-_](\d{2})[.\-_](\d{2})`)
+	compactDailyIndexRegex = regexp.MustCompile(`(?:^|\D)(\d{4})(\d{2})(\d{2})(?:\D|$)`)
+	monthlyIndexRegex      = regexp.MustCompile(`(\d{4})[.\-_](\d{2})(?:\D|$)`)
+)
+
+// getIndexDate reads the date from time based index names, periodDays is the time span a single index covers.
+func getIndexDate(index string) (date time.Time, periodDays int, ok bool) {
+	for _, format := range []struct {
+		regex      *regexp.Regexp
+		periodDays int
+	}{
+		{dailyIndexRegex, 1},
+		{compactDailyIndexRegex, 1},
+		{monthlyIndexRegex, 30},
+	} {
+		match := format.regex.FindStringSubmatch(index)
+		if match == nil {
+			continue
+		}
+		day := "01"
+		if len(match) > 3 {
+			day = match[3]
+		}
+		date, err := time.Parse("2006-01-02", match[1]+"-"+match[2]+"-"+day)
+		if err != nil {
+			continue
+		}
+		return date, format.periodDays, true
+	}
+	return
+}
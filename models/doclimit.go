@@ -0,0 +1,136 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	DefaultDocLimitFraction   = 0.5       // share of LuceneMaxDocsPerShard a shard is sized to hold at most
+	DocLimitWarningDays       = 90        // projections reaching the limit sooner are reported
+	MaxDocLimitProjectionDays = 100 * 365 // projections reaching the limit later are not reachable and have no date
+)
+
+// SizingConstraint names what decided the recommended primary shard count of an index.
+type SizingConstraint string
+
+const (
+	SizedBySize     SizingConstraint = "size"      // TargetShardSizeGB
+	SizedByDocs     SizingConstraint = "docs"      // TargetDocsPerShard
+	SizedByDocLimit SizingConstraint = "doc_limit" // the share of LuceneMaxDocsPerShard a shard may hold
+)
+
+type DocLimitProjection struct {
+	DocsPerShardPerDay float64    `json:"docs_per_shard_per_day"`
+	DaysToLimit        float64    `json:"days_to_limit"`
+	LimitDate          *time.Time `json:"limit_date,omitempty"`  // nil when not reachable
+	Unreachable        bool       `json:"unreachable,omitempty"` // the limit is more than MaxDocLimitProjectionDays away
+}
+
+func (c *Cluster) getMaxDocsPerShard() int64 {
+	fraction := c.DocLimitFraction
+	if fraction <= 0 || fraction > 1 {
+		fraction = DefaultDocLimitFraction
+	}
+	return int64(fraction * LuceneMaxDocsPerShard)
+}
+
+// indexDates caches the dates read from the index names of a pattern.
+type indexDates struct {
+	dated      map[string]time.Time
+	newest     string
+	periodDays int
+	docsPerDay float64
+	hasRate    bool
+}
+
+// getDatedIndices returns the indices with a date in their name, the newest index and the period one index covers.
+func (ipr *IndexPatternRollup) getDatedIndices() *indexDates {
+	if ipr.dates != nil {
+		return ipr.dates
+	}
+	dates := &indexDates{dated: map[string]time.Time{}}
+	for name := range ipr.Indices {
+		date, period, ok := getIndexDate(name)
+		if !ok {
+			continue
+		}
+		dates.dated[name] = date
+		dates.periodDays = period
+		newest, found := dates.dated[dates.newest]
+		if !found || date.After(newest) || (date.Equal(newest) && name > dates.newest) {
+			dates.newest = name
+		}
+	}
+	// the ingest rate comes from the completed indices only
+	var docs int64
+	var completed int
+	for name := range dates.dated {
+		if name == dates.newest || name == ipr.GetWriteIndex() {
+			continue
+		}
+		docs += ipr.Indices[name].PrimaryDocs
+		completed++
+	}
+	if completed > 0 {
+		dates.docsPerDay = float64(docs) / float64(completed*dates.periodDays)
+		dates.hasRate = true
+	}
+	ipr.dates = dates
+	return dates
+}
+
+// getDocsPerDay estimates the docs the pattern takes in per day from its completed dated indices.
+func (ipr *IndexPatternRollup) getDocsPerDay() (docsPerDay float64, ok bool) {
+	dates := ipr.getDatedIndices()
+	return dates.docsPerDay, dates.hasRate
+}
+
+// isGrowingIndex reports whether the index still takes in docs: the write index of a data stream or
+// rollover alias, or the newest index of a time based pattern.
+func (ipr *IndexPatternRollup) isGrowingIndex(ir *IndexRollup) bool {
+	if writeIndex := ipr.GetWriteIndex(); writeIndex != "" {
+		return ir.IndexName == writeIndex
+	}
+	return ir.IndexName == ipr.getDatedIndices().newest
+}
+
+// getExpectedPrimaryDocs returns the docs the index will hold once complete, the docs of a growing time
+// based index are projected to the end of its period.
+func (ipr *IndexPatternRollup) getExpectedPrimaryDocs(ir *IndexRollup) int64 {
+	if !ipr.isGrowingIndex(ir) {
+		return ir.PrimaryDocs
+	}
+	dates := ipr.getDatedIndices()
+	if dates.newest == "" || !dates.hasRate {
+		return ir.PrimaryDocs
+	}
+	expected := int64(dates.docsPerDay * float64(dates.periodDays))
+	if expected > ir.PrimaryDocs {
+		return expected
+	}
+	return ir.PrimaryDocs
+}
+
+// getDocLimitProjection projects when the largest shard of a growing index hits LuceneMaxDocsPerShard
+// at the ingest rate of the pattern.
+func (ipr *IndexPatternRollup) getDocLimitProjection(ir *IndexRollup, maxShardDocs int64, now time.Time) *DocLimitProjection {
+	if ir.Primaries == 0 || !ipr.isGrowingIndex(ir) {
+		return nil
+	}
+	docsPerDay, ok := ipr.getDocsPerDay()
+	if !ok || docsPerDay <= 0 {
+		return nil
+	}
+	perShard := docsPerDay / float64(ir.Primaries)
+	days := math.Max(0, float64(LuceneMaxDocsPerShard-maxShardDocs)/perShard)
+	projection := &DocLimitProjection{DocsPerShardPerDay: perShard, DaysToLimit: days}
+	if days > MaxDocLimitProjectionDays {
+		// a duration of centuries overflows, and the date means nothing anyway
+		projection.Unreachable = true
+		return projection
+	}
+	limitDate := now.Add(time.Duration(days*24) * time.Hour).Truncate(24 * time.Hour)
+	projection.LimitDate = &limitDate
+	return projection
+}
@@ -0,0 +1,100 @@
+package models
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func Test_getIndexDate(t *testing.T) {
+	date, period, ok := getIndexDate("logs-2024.01.31")
+	assert.True(t, ok)
+	assert.Equal(t, time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC), date)
+	assert.Equal(t, 1, period)
+
+	_, period, ok = getIndexDate("metrics-20240131")
+	assert.True(t, ok)
+	assert.Equal(t, 1, period)
+
+	_, period, ok = getIndexDate("billing-2024-01")
+	assert.True(t, ok)
+	assert.Equal(t, 30, period)
+
+	_, _, ok = getIndexDate("logs-000001")
+	assert.False(t, ok)
+}
+
+func Test_docLimitRaisesShardCount(t *testing.T) {
+	// 3 billion tiny docs a day in a single 10gb shard
+	var shards []ShardStats
+	for _, day := range []string{"01", "02", "03"} {
+		shards = append(shards, ShardStats{Index: "metrics-2024.01." + day, Type: "p", Docs: 3000000000, StoreSize: 10 * gb, Node: "n1"})
+	}
+	shards = append(shards, ShardStats{Index: "metrics-2024.01.04", Type: "p", Docs: 1000000000, StoreSize: 3 * gb, Node: "n1"})
+	c := newTestCluster(shards...)
+	c.Now = func() time.Time { return time.Date(2024, 1, 4, 12, 0, 0, 0, time.UTC) }
+	reco := c.PrepareRecommendation()
+
+	ipr := reco.IndexPatternRecommendationRollup[0]
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), ipr.OldestIndexTime)
+	for _, ir := range ipr.Indices {
+		// 3 billion docs need 3 shards to stay below half of the limit
+		assert.Equal(t, 3, ir.PotentialPrimaries, ir.Name)
+	}
+	newest := ipr.Indices[3]
+	assert.NotNil(t, newest.DocLimitProjection)
+	assert.InDelta(t, 0.38, newest.DocLimitProjection.DaysToLimit, 0.01)
+	assert.Equal(t, time.Date(2024, 1, 4, 0, 0, 0, 0, time.UTC), *newest.DocLimitProjection.LimitDate)
+	var projected []Finding
+	for _, f := range reco.Findings {
+		if f.Type == FindingDocLimit {
+			projected = append(projected, f)
+		}
+	}
+	assert.Len(t, projected, 1)
+	assert.Equal(t, SeverityCritical, projected[0].Severity)
+	assert.Equal(t, "metrics-2024.01.04", projected[0].Index)
+}
+
+func Test_docLimitProjectionOfSlowIngest(t *testing.T) {
+	// 10 docs a day take billions of years to reach the limit, far past what a time.Duration holds
+	c := newTestCluster(
+		ShardStats{Index: "audit-2024.01.01", Type: "p", Docs: 10, StoreSize: 1000, Node: "n1"},
+		ShardStats{Index: "audit-2024.01.02", Type: "p", Docs: 10, StoreSize: 1000, Node: "n1"},
+		ShardStats{Index: "audit-2024.01.03", Type: "p", Docs: 5, StoreSize: 500, Node: "n1"},
+	)
+	c.Now = func() time.Time { return time.Date(2024, 1, 3, 0, 0, 0, 0, time.UTC) }
+	reco := c.PrepareRecommendation()
+
+	var projection *DocLimitProjection
+	for _, ir := range reco.IndexPatternRecommendationRollup[0].Indices {
+		if ir.DocLimitProjection != nil {
+			projection = ir.DocLimitProjection
+		}
+	}
+	if assert.NotNil(t, projection) {
+		assert.True(t, projection.Unreachable)
+		assert.Nil(t, projection.LimitDate)
+		assert.Greater(t, projection.DaysToLimit, float64(MaxDocLimitProjectionDays))
+	}
+}
+
+func Test_targetDocsPerShard(t *testing.T) {
+	c := newTestCluster(
+		ShardStats{Index: "metrics", Shard: 0, Type: "p", Docs: 400000000, StoreSize: 5 * gb, Node: "n1"},
+		ShardStats{Index: "small", Shard: 0, Type: "p", Docs: 1000, StoreSize: 40 * gb, Node: "n2"},
+	)
+	c.TargetDocsPerShard = 100000000
+	reco := c.PrepareRecommendation()
+
+	constraints := map[string]SizingConstraint{}
+	for _, ipr := range reco.IndexPatternRecommendationRollup {
+		for _, ir := range ipr.Indices {
+			constraints[ir.Name] = ir.SizingConstraint
+			if ir.Name == "metrics" {
+				assert.Equal(t, 4, ir.PotentialPrimaries)
+			}
+		}
+	}
+	assert.Equal(t, map[string]SizingConstraint{"metrics": SizedByDocs, "small": SizedBySize}, constraints)
+}
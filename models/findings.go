@@ -0,0 +1,181 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+)
+
+// LuceneMaxDocsPerShard is the hard limit of documents a Lucene index, and so a shard, can hold.
+const LuceneMaxDocsPerShard = 2147483519
+
+const (
+	DefaultLargeShardGB = 50
+	DefaultSmallShardMB = 1024
+	DefaultMaxShardDocs = LuceneMaxDocsPerShard / 2
+)
+
+type Severity string
+
+const (
+	SeverityInfo     Severity = "info"
+	SeverityWarning  Severity = "warning"
+	SeverityCritical Severity = "critical"
+)
+
+var severityOrder = map[Severity]int{SeverityCritical: 0, SeverityWarning: 1, SeverityInfo: 2}
+
+type FindingType string
+
+const (
+	FindingLargeShard    FindingType = "large_shard"
+	FindingSmallShards   FindingType = "small_shards"
+	FindingShardDocCount FindingType = "shard_doc_count"
+	FindingDocLimit      FindingType = "doc_limit_projection"
+	FindingPrimaries     FindingType = "primaries_over_data_nodes"
+	FindingRoutingSkew   FindingType = "routing_skew"
+	FindingBloat         FindingType = "deleted_docs_bloat"
+	FindingForceMerge    FindingType = "force_merge"
+	FindingCleanup       FindingType = "cleanup"
+	FindingSkippedInput  FindingType = "skipped_input"
+)
+
+type Finding struct {
+	Type        FindingType `json:"type"`
+	Severity    Severity    `json:"severity"`
+	Index       string      `json:"index,omitempty"`
+	Pattern     string      `json:"pattern,omitempty"`
+	Message     string      `json:"message"`
+	Remediation string      `json:"remediation"`
+}
+
+// SkippedInput is a cat API the domain did not answer, the report is built without it.
+type SkippedInput struct {
+	Path   string `json:"path"`
+	Reason string `json:"reason"`
+	Effect string `json:"effect"` // what the report misses without it
+}
+
+type FindingThresholds struct {
+	LargeShardGB int   `json:"large_shard_gb"` // shards above are reported as too large
+	SmallShardMB int   `json:"small_shard_mb"` // indices with several primaries all below are reported as over-sharded
+	MaxShardDocs int64 `json:"max_shard_docs"` // shards above are getting close to LuceneMaxDocsPerShard
+}
+
+func NewFindingThresholds() FindingThresholds {
+	return FindingThresholds{
+		LargeShardGB: DefaultLargeShardGB,
+		SmallShardMB: DefaultSmallShardMB,
+		MaxShardDocs: DefaultMaxShardDocs,
+	}
+}
+
+// withDefaults fills the unset thresholds with the defaults.
+func (t FindingThresholds) withDefaults() FindingThresholds {
+	defaults := NewFindingThresholds()
+	if t.LargeShardGB <= 0 {
+		t.LargeShardGB = defaults.LargeShardGB
+	}
+	if t.SmallShardMB <= 0 {
+		t.SmallShardMB = defaults.SmallShardMB
+	}
+	if t.MaxShardDocs <= 0 {
+		t.MaxShardDocs = defaults.MaxShardDocs
+	}
+	return t
+}
+
+// BuildFindings collects everything the analysis flagged into one list, most severe first.
+func (r *Recommendation) BuildFindings() {
+	t := r.Thresholds
+	largeShardBytes := int64(t.LargeShardGB) * 1024 * 1024 * 1024
+	smallShardBytes := int64(t.SmallShardMB) * 1024 * 1024
+	r.Findings = []Finding{}
+	for _, ipr := range r.IndexPatternRecommendationRollup {
+		if ipr.IsIndependentIndexPattern() {
+			continue
+		}
+		for _, ir := range ipr.Indices {
+			if ir.MaxShardSizeInBytes > largeShardBytes {
+				severity := SeverityWarning
+				if ir.MaxShardSizeInBytes > 2*largeShardBytes {
+					severity = SeverityCritical
+				}
+				r.addFinding(FindingLargeShard, severity, ir.Name, ipr.Pattern,
+					fmt.Sprintf("Largest shard holds %s, above %d GB", ByteCountIEC(ir.MaxShardSizeInBytes), t.LargeShardGB),
+					fmt.Sprintf("Use %d primary shards, or roll over before the shards grow this large", ir.PotentialPrimaries))
+			}
+			if ir.Primaries > 1 && ir.MaxShardSizeInBytes < smallShardBytes && !ir.IsWriteIndex {
+				r.addFinding(FindingSmallShards, SeverityInfo, ir.Name, ipr.Pattern,
+					fmt.Sprintf("%d primary shards all below %d MB", ir.Primaries, t.SmallShardMB),
+					fmt.Sprintf("Use %d primary shards through the index template, or shrink the index", ir.PotentialPrimaries))
+			}
+			if ir.MaxShardDocs > t.MaxShardDocs {
+				severity := SeverityWarning
+				if float64(ir.MaxShardDocs) > 0.9*LuceneMaxDocsPerShard {
+					severity = SeverityCritical
+				}
+				r.addFinding(FindingShardDocCount, severity, ir.Name, ipr.Pattern,
+					fmt.Sprintf("A shard holds %d docs, the limit per shard is %d", ir.MaxShardDocs, int64(LuceneMaxDocsPerShard)),
+					"Split the index or add primary shards before indexing fails")
+			}
+			if p := ir.DocLimitProjection; p != nil && p.DaysToLimit < DocLimitWarningDays {
+				severity := SeverityWarning
+				if p.DaysToLimit < DocLimitWarningDays/3 {
+					severity = SeverityCritical
+				}
+				r.addFinding(FindingDocLimit, severity, ir.Name, ipr.Pattern,
+					fmt.Sprintf("At %.0f docs per shard a day the largest shard reaches the doc limit in %.0f days (%s)", p.DocsPerShardPerDay, p.DaysToLimit, p.LimitDate.Format("2006-01-02")),
+					fmt.Sprintf("Roll over sooner or use %d primary shards", ir.PotentialPrimaries))
+			}
+			if r.NumberOfDataNodes > 0 && ir.Primaries > r.NumberOfDataNodes {
+				r.addFinding(FindingPrimaries, SeverityWarning, ir.Name, ipr.Pattern,
+					fmt.Sprintf("%d primary shards on %d data nodes", ir.Primaries, r.NumberOfDataNodes),
+					"Keep the primary shards at or below the number of data nodes unless the shards are large")
+			}
+		}
+	}
+	for _, skew := range r.SkewedIndices {
+		r.addFinding(FindingRoutingSkew, SeverityWarning, skew.Name, skew.Pattern,
+			fmt.Sprintf("Largest shard is %.1fx the median shard", skew.SkewRatio), skew.Message)
+	}
+	for _, ib := range r.BloatedIndices {
+		r.addFinding(FindingBloat, SeverityInfo, ib.Name, ib.Pattern,
+			fmt.Sprintf("%.1f%% of the docs are deleted, %s can be reclaimed", ib.DeletedDocsRatio*100, ByteCountIEC(ib.ReclaimableBytes)),
+			ib.Remediation+": "+ib.Command)
+	}
+	for _, sr := range r.SegmentRecommendations {
+		if sr.NeedsForceMerge {
+			r.addFinding(FindingForceMerge, SeverityInfo, sr.Name, "",
+				fmt.Sprintf("%d segments on a rolled index", sr.Segments), sr.Command)
+		}
+	}
+	for _, ci := range r.CleanupIndices {
+		message := fmt.Sprintf("%d docs in %d shards", ci.Docs, ci.GetShardCount())
+		if ci.Closed {
+			message = "Closed index"
+		}
+		r.addFinding(FindingCleanup, SeverityInfo, ci.Name, "", message, "DELETE /"+ci.Name)
+	}
+	for _, si := range r.SkippedInputs {
+		r.addFinding(FindingSkippedInput, SeverityWarning, "", "",
+			fmt.Sprintf("%s could not be read (%s), %s", si.Path, si.Reason, si.Effect),
+			"Allow GET /"+si.Path+" for the user or role of the analyzer")
+	}
+	sort.SliceStable(r.Findings, func(i, j int) bool {
+		if r.Findings[i].Severity != r.Findings[j].Severity {
+			return severityOrder[r.Findings[i].Severity] < severityOrder[r.Findings[j].Severity]
+		}
+		return r.Findings[i].Index < r.Findings[j].Index
+	})
+}
+
+func (r *Recommendation) addFinding(findingType FindingType, severity Severity, index string, pattern string, message string, remediation string) {
+	r.Findings = append(r.Findings, Finding{
+		Type:        findingType,
+		Severity:    severity,
+		Index:       index,
+		Pattern:     pattern,
+		Message:     message,
+		Remediation: remediation,
+	})
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_findings(t *testing.T) {
+	var shards []ShardStats
+	for i := 0; i < 3; i++ {
+		shards = append(shards, ShardStats{Index: "tiny", Shard: i, Type: "p", Docs: 10, StoreSize: 1024, Node: "n1"})
+	}
+	shards = append(shards,
+		ShardStats{Index: "huge", Shard: 0, Type: "p", Docs: 1500000000, StoreSize: 60 * gb, Node: "n1"},
+		ShardStats{Index: "huge", Shard: 1, Type: "p", Docs: 10, StoreSize: 60 * gb, Node: "n2"},
+	)
+	c := newTestCluster(shards...)
+	c.Thresholds = FindingThresholds{LargeShardGB: 55}
+	reco := c.PrepareRecommendation()
+
+	assert.Equal(t, 55, reco.Thresholds.LargeShardGB)
+	assert.Equal(t, DefaultSmallShardMB, reco.Thresholds.SmallShardMB)
+	var types []FindingType
+	for _, f := range reco.Findings {
+		types = append(types, f.Type)
+	}
+	assert.Equal(t, []FindingType{FindingLargeShard, FindingShardDocCount, FindingPrimaries, FindingSmallShards}, types)
+	assert.Equal(t, SeverityWarning, reco.Findings[0].Severity)
+	assert.Equal(t, "huge", reco.Findings[0].Index)
+}
@@ -2,24 +2,43 @@ package models
 
 import (
 	"strconv"
+	"strings"
 )
 
 type IndexPatternRollup struct {
-	Pattern string
-	Parent  *Cluster
-	Indices map[string]*IndexRollup
+	Pattern    string
+	Parent     *Cluster
+	Indices    map[string]*IndexRollup
+	DataStream *DataStream    // set when the pattern groups the backing indices of a data stream
+	Alias      *RolloverAlias // set when the pattern groups the indices of a rollover alias
+	dates      *indexDates
+}
+
+// GetWriteIndex returns the index still being written to, if the pattern is a data stream or a rollover alias.
+func (ipr *IndexPatternRollup) GetWriteIndex() string {
+	if ipr.DataStream != nil {
+		return ipr.DataStream.WriteIndex
+	}
+	if ipr.Alias != nil {
+		return ipr.Alias.getWriteIndex()
+	}
+	return ""
 }
 
 type IndexRollup struct {
 	IndexName        string
+	Class            IndexClass
 	PrimarySizeBytes int64
 	ReplicaSizeBytes int64
 	Primaries        int
 	Replicas         int
 	Shards           map[string]*ShardStats
-	Docs             int64
+	Docs             int64 // of primaries and replicas
+	PrimaryDocs      int64
 	Nodes            map[string]*IndexNodeStats
 	Parent           *IndexPatternRollup
+	Segments         *SegmentStats // only with _cat/segments input
+	Stats            *IndexStats   // only with _cat/indices input
 }
 
 func (ir *IndexRollup) add(status ShardStats) {
@@ -34,6 +53,7 @@ func (ir *IndexRollup) add(status ShardStats) {
 	indexNodeStats.adjustShardCountFor(status)
 	if status.isPrimary() {
 		ir.PrimarySizeBytes += status.StoreSize
+		ir.PrimaryDocs += status.Docs
 		ir.Primaries++
 	} else {
 		ir.ReplicaSizeBytes += status.StoreSize
@@ -45,6 +65,19 @@ func (ir *IndexRollup) IsEmpty() bool {
 	return ir.Docs <= 0
 }
 
+// IsClosed reports whether _cat/indices lists the index as closed, or any of its shards was listed in the CLOSED state.
+func (ir *IndexRollup) IsClosed() bool {
+	if ir.Stats != nil && ir.Stats.isClosed() {
+		return true
+	}
+	for _, shard := range ir.Shards {
+		if strings.EqualFold(shard.State, "CLOSED") {
+			return true
+		}
+	}
+	return false
+}
+
 func (ir *IndexRollup) IsPotentialUWIndex() bool {
 	return ir.Replicas == 0
 }
@@ -1,6 +1,9 @@
 package models
 
-import "sort"
+import (
+	"fmt"
+	"sort"
+)
 
 func getCeilingDivisible(num int, divisibleBy int) int {
 	if num%divisibleBy == 0 {
@@ -48,3 +51,17 @@ func removeDuplicateValues(intSlice []int) []int {
 	}
 	return list
 }
+
+func ByteCountIEC(b int64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%d B", b)
+	}
+	div, exp := int64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB",
+		float64(b)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,108 @@
+package models
+
+import "strconv"
+
+const (
+	TinySegmentSizeBytes     = 2 * 1024 * 1024 // below the default floor segment size of the merge policy
+	ForceMergeDeletedRatio   = 0.2             // deleted docs ratio worth a forcemerge on its own
+	forceMergeTargetSegments = 1
+)
+
+// SegmentStats sums up the segments of the primary shards of an index, replicas hold the same segments.
+type SegmentStats struct {
+	Segments        int
+	Docs            int64
+	DeletedDocs     int64
+	SizeBytes       int64
+	TinyUncommitted int
+}
+
+func (ss *SegmentStats) add(as AllocationStats) {
+	ss.Segments++
+	ss.Docs += int64(as.Docs)
+	ss.DeletedDocs += int64(as.DeletedDocs)
+	ss.SizeBytes += as.Size
+	if !as.Committed && as.Size < TinySegmentSizeBytes {
+		ss.TinyUncommitted++
+	}
+}
+
+func (ss *SegmentStats) getDeletedDocsRatio() float64 {
+	if ss.Docs+ss.DeletedDocs == 0 {
+		return 0
+	}
+	return float64(ss.DeletedDocs) / float64(ss.Docs+ss.DeletedDocs)
+}
+
+type IndexSegmentRecommendation struct {
+	Name                    string  `json:"name"`
+	Segments                int     `json:"segments"`
+	SegmentsPerShard        float64 `json:"segments_per_shard"`
+	DeletedDocsRatio        float64 `json:"deleted_docs_ratio"`
+	TinyUncommittedSegments int     `json:"tiny_uncommitted_segments"`
+	IsRolled                bool    `json:"is_rolled"`
+	NeedsForceMerge         bool    `json:"needs_force_merge"`
+	Command                 string  `json:"command,omitempty"`
+}
+
+// AddSegment adds a line of _cat/segments to its index, the shards have to be added first.
+func (c *Cluster) AddSegment(as AllocationStats) {
+	ir := c.indices[as.Index]
+	if ir == nil || !as.isPrimary() {
+		return
+	}
+	if ir.Segments == nil {
+		ir.Segments = &SegmentStats{}
+	}
+	ir.Segments.add(as)
+}
+
+// isRolledIndex reports whether the index no longer receives writes: it is not the write index of its
+// data stream or alias, it is older than the newest index of its pattern, or it has no replicas as
+// warm indices do.
+func (ipr *IndexPatternRollup) isRolledIndex(ir *IndexRollup) bool {
+	if writeIndex := ipr.GetWriteIndex(); writeIndex != "" {
+		return ir.IndexName != writeIndex
+	}
+	if ir.IsPotentialUWIndex() {
+		return true
+	}
+	for name := range ipr.Indices {
+		if name > ir.IndexName {
+			return true
+		}
+	}
+	return false
+}
+
+func (ipr *IndexPatternRollup) getSegmentRecommendation(ir *IndexRollup) *IndexSegmentRecommendation {
+	if ir.Segments == nil || ir.Primaries == 0 {
+		return nil
+	}
+	sr := &IndexSegmentRecommendation{
+		Name:                    ir.IndexName,
+		Segments:                ir.Segments.Segments,
+		SegmentsPerShard:        float64(ir.Segments.Segments) / float64(ir.Primaries),
+		DeletedDocsRatio:        ir.Segments.getDeletedDocsRatio(),
+		TinyUncommittedSegments: ir.Segments.TinyUncommitted,
+		IsRolled:                ipr.isRolledIndex(ir),
+	}
+	if sr.IsRolled && (sr.SegmentsPerShard > forceMergeTargetSegments || sr.DeletedDocsRatio >= ForceMergeDeletedRatio) {
+		sr.NeedsForceMerge = true
+		sr.Command = GetForceMergeCommand(ir.IndexName, forceMergeTargetSegments)
+	}
+	return sr
+}
+
+func GetForceMergeCommand(index string, maxSegments int) string {
+	return "POST /" + index + "/_forcemerge?max_num_segments=" + strconv.Itoa(maxSegments)
+}
+
+func (r *Recommendation) GetForceMergeCommands() (commands []string) {
+	for _, sr := range r.SegmentRecommendations {
+		if sr.NeedsForceMerge {
+			commands = append(commands, sr.Command)
+		}
+	}
+	return
+}
@@ -22,14 +22,27 @@ func (sc *ShardCounter) getIdealShardCount(primaries int, totalPrimarySize int64
 	idealCount := int(math.Ceil(factor))
 	if totalPrimarySize < targetPrimarySize {
 		return 1
-	} else {
-		if idealCount == 1 {
-			return 1
-		}
-		for _, v := range sc.PossibleCounts {
-			if v >= idealCount {
-				return v
-			}
+	}
+	return sc.getDistributedShardCount(primaries, idealCount)
+}
+
+// getShardCountForDocs returns the shard count that keeps every shard below maxDocsPerShard.
+func (sc *ShardCounter) getShardCountForDocs(primaries int, docs int64, maxDocsPerShard int64) int {
+	if docs <= maxDocsPerShard || maxDocsPerShard <= 0 {
+		return 1
+	}
+	idealCount := int(math.Ceil(float64(docs) / float64(maxDocsPerShard)))
+	return sc.getDistributedShardCount(primaries, idealCount)
+}
+
+// getDistributedShardCount raises the idealCount to a count that spreads evenly over the data nodes and AZs.
+func (sc *ShardCounter) getDistributedShardCount(primaries int, idealCount int) int {
+	if idealCount == 1 {
+		return 1
+	}
+	for _, v := range sc.PossibleCounts {
+		if v >= idealCount {
+			return v
 		}
 	}
 	// add the remainder if the idealCount is not evenly distributed.
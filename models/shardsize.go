@@ -0,0 +1,106 @@
+package models
+
+import (
+	"math"
+	"sort"
+)
+
+const (
+	DefaultSkewFactor = 3.0                // largest shard compared to the median shard of the index
+	SkewMinShardBytes = 1024 * 1024 * 1024 // skew between smaller shards does not matter
+)
+
+type ShardSizeStats struct {
+	MaxDocs int64
+	Max     int64
+	Min     int64
+	Median  int64
+	Mean    float64
+	StdDev  float64
+}
+
+// getShardSizeStats describes the spread of the primary shard sizes of the index.
+func (ir *IndexRollup) getShardSizeStats() (stats ShardSizeStats) {
+	var sizes []int64
+	for _, shard := range ir.Shards {
+		if shard.isPrimary() {
+			sizes = append(sizes, shard.StoreSize)
+			if shard.Docs > stats.MaxDocs {
+				stats.MaxDocs = shard.Docs
+			}
+		}
+	}
+	if len(sizes) == 0 {
+		return
+	}
+	sort.Slice(sizes, func(i, j int) bool {
+		return sizes[i] < sizes[j]
+	})
+	stats.Min, stats.Max = sizes[0], sizes[len(sizes)-1]
+	mid := len(sizes) / 2
+	if len(sizes)%2 == 0 {
+		stats.Median = (sizes[mid-1] + sizes[mid]) / 2
+	} else {
+		stats.Median = sizes[mid]
+	}
+	var sum float64
+	for _, size := range sizes {
+		sum += float64(size)
+	}
+	stats.Mean = sum / float64(len(sizes))
+	var variance float64
+	for _, size := range sizes {
+		variance += math.Pow(float64(size)-stats.Mean, 2)
+	}
+	stats.StdDev = math.Sqrt(variance / float64(len(sizes)))
+	return
+}
+
+type ShardSkew struct {
+	Name                   string  `json:"name"`
+	Pattern                string  `json:"pattern"`
+	Primaries              int     `json:"primaries"`
+	MaxShardSizeInBytes    int64   `json:"max_shard_size_in_bytes"`
+	MedianShardSizeInBytes int64   `json:"median_shard_size_in_bytes"`
+	MinShardSizeInBytes    int64   `json:"min_shard_size_in_bytes"`
+	ShardSizeStdDev        float64 `json:"shard_size_std_dev"`
+	SkewRatio              float64 `json:"skew_ratio"`
+	Message                string  `json:"message"`
+}
+
+// getShardSkew flags the index when its largest shard is skewFactor times the median shard, which
+// points at documents piling up on few shards through custom routing.
+func (ir *IndexRollup) getShardSkew(pattern string, stats ShardSizeStats, skewFactor float64) *ShardSkew {
+	if ir.Primaries < 2 || stats.Max < SkewMinShardBytes {
+		return nil
+	}
+	ratio := math.Inf(1)
+	if stats.Median > 0 {
+		ratio = float64(stats.Max) / float64(stats.Median)
+	}
+	if ratio < skewFactor {
+		return nil
+	}
+	skew := &ShardSkew{
+		Name:                   ir.IndexName,
+		Pattern:                pattern,
+		Primaries:              ir.Primaries,
+		MaxShardSizeInBytes:    stats.Max,
+		MedianShardSizeInBytes: stats.Median,
+		MinShardSizeInBytes:    stats.Min,
+		ShardSizeStdDev:        stats.StdDev,
+		SkewRatio:              ratio,
+		Message:                "Shards are unevenly filled, check the custom routing values or spread them with index.routing_partition_size",
+	}
+	if math.IsInf(ratio, 1) {
+		skew.SkewRatio = 0 // JSON has no infinity, a zero median is shown as is
+	}
+	return skew
+}
+
+func (c *Cluster) getSkewFactor() float64 {
+	if c.SkewFactor <= 1 {
+		return DefaultSkewFactor
+	}
+	return c.SkewFactor
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+const gb = 1024 * 1024 * 1024
+
+func Test_routingSkew(t *testing.T) {
+	shards := []ShardStats{{Index: "orders", Shard: 0, Type: "p", Docs: 100, StoreSize: 120 * gb, Node: "n1"}}
+	for i := 1; i < 6; i++ {
+		shards = append(shards, ShardStats{Index: "orders", Shard: i, Type: "p", Docs: 100, StoreSize: 5 * gb, Node: "n2"})
+	}
+	c := newTestCluster(shards...)
+	c.IsSearchWorkload = true
+	reco := c.PrepareRecommendation()
+
+	assert.Len(t, reco.SkewedIndices, 1)
+	skew := reco.SkewedIndices[0]
+	assert.Equal(t, int64(120*gb), skew.MaxShardSizeInBytes)
+	assert.Equal(t, int64(5*gb), skew.MedianShardSizeInBytes)
+	assert.InDelta(t, 24, skew.SkewRatio, 0.01)
+
+	// the average shard is 24gb, only the largest shard goes over the threshold
+	found, indices := reco.GetIndicesWithLargerShards(50)
+	assert.True(t, found)
+	assert.Equal(t, "orders", indices[0].Name)
+}
@@ -3,7 +3,6 @@ package parser
 import (
 	"encoding/csv"
 	"errors"
-	"fmt"
 	"golang.org/x/text/unicode/norm"
 	"io"
 	"reflect"
@@ -11,6 +10,8 @@ import (
 	"strings"
 )
 
+const DefaultShardsHeader = "index              shard prirep state      docs   store ip            node"
+
 type Parser struct {
 	Headers    []string
 	Reader     *csv.Reader
@@ -24,6 +25,7 @@ type Parser struct {
 func NewParser(reader io.Reader, data interface{}) (*Parser, error) {
 	r := csv.NewReader(reader)
 	r.Comma = '\t'
+	r.ReuseRecord = true // records are split into new strings right away
 
 	// first line should be fields
 	headers, err := r.Read()
@@ -33,8 +35,8 @@ func NewParser(reader io.Reader, data interface{}) (*Parser, error) {
 	}
 
 	//if headers missing, assume shards header
-	if headers[0] != "" || !strings.HasPrefix(headers[0], "index ") {
-		headers[0] = "index              shard prirep state      docs   store ip            node"
+	if len(headers) == 0 || strings.TrimSpace(headers[0]) == "" {
+		headers = []string{DefaultShardsHeader}
 	}
 	// header have empty spaces
 	actualRecords := strings.Split(headers[0], " ")
@@ -84,6 +86,7 @@ func NewParser(reader io.Reader, data interface{}) (*Parser, error) {
 func NewParserWithoutHeader(reader io.Reader, data interface{}) *Parser {
 	r := csv.NewReader(reader)
 	r.Comma = '\t'
+	r.ReuseRecord = true // records are split into new strings right away
 
 	p := &Parser{
 		Reader:    r,
@@ -110,7 +113,6 @@ func (p *Parser) Next() (eof bool, err error) {
 			}
 			return false, err
 		}
-		fmt.Println(records)
 		if len(records) > 0 {
 			actualRecords := strings.Fields(records[0])
 			records = deleteEmpty(actualRecords)
@@ -126,6 +128,9 @@ func (p *Parser) Next() (eof bool, err error) {
 		}
 	}
 
+	// reset the previous row, short rows (e.g. unassigned shards) must not inherit its values
+	p.ref.Set(reflect.Zero(p.ref.Type()))
+
 	// record should be a pointer
 	for i, record := range records {
 		if i >= len(p.indices) {
@@ -0,0 +1,360 @@
+package reports
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"shardanalyzer/models"
+	"sort"
+	"strconv"
+)
+
+const (
+	chartWidth = 1200
+	chartScale = 2 // of the bitmap font, 10x14 pixels per character
+	lineHeight = (glyphHeight + 4) * chartScale
+)
+
+// the colors of the charts that don't follow the theme, green for proposed values
+var (
+	chartGreen = color.RGBA{40, 150, 90, 255}
+	chartText  = color.RGBA{40, 40, 40, 255}
+	chartGrid  = color.RGBA{230, 230, 230, 255}
+	chartWhite = color.RGBA{255, 255, 255, 255}
+)
+
+// chartPalette is the theme of the charts, primary for current values and primary shards
+type chartPalette struct {
+	primary, accent, stripe color.RGBA
+	shades                  []color.RGBA // of the treemap tiles, dark to light
+}
+
+var defaultPalette = chartPalette{
+	primary: color.RGBA{10, 10, 150, 255},
+	accent:  color.RGBA{185, 217, 235, 255},
+	stripe:  color.RGBA{217, 225, 226, 255},
+	shades:  []color.RGBA{{10, 10, 150, 255}, {40, 90, 180, 255}, {70, 140, 200, 255}, {110, 170, 215, 255}, {150, 195, 228, 255}, {185, 217, 235, 255}},
+}
+
+// getChartPalette returns the colors as RGBA, the shades of other themes blend primary into accent
+func (c pdfColors) getChartPalette() chartPalette {
+	p := chartPalette{
+		primary: color.RGBA{uint8(c.primary.Red), uint8(c.primary.Green), uint8(c.primary.Blue), 255},
+		accent:  color.RGBA{uint8(c.accent.Red), uint8(c.accent.Green), uint8(c.accent.Blue), 255},
+		stripe:  color.RGBA{uint8(c.stripe.Red), uint8(c.stripe.Green), uint8(c.stripe.Blue), 255},
+	}
+	if p.primary == defaultPalette.primary && p.accent == defaultPalette.accent {
+		p.shades = defaultPalette.shades
+		return p
+	}
+	steps := len(defaultPalette.shades) - 1
+	for i := 0; i <= steps; i++ {
+		blend := func(from, to uint8) uint8 {
+			return uint8((int(from)*(steps-i) + int(to)*i) / steps)
+		}
+		p.shades = append(p.shades, color.RGBA{blend(p.primary.R, p.accent.R), blend(p.primary.G, p.accent.G), blend(p.primary.B, p.accent.B), 255})
+	}
+	return p
+}
+
+// upper bounds in GB of the buckets of the shard size histogram, the last bucket is open
+var histogramBoundsGB = []int{1, 5, 10, 30, 50}
+
+func getHistogramLabels() []string {
+	labels := []string{"<" + strconv.Itoa(histogramBoundsGB[0]) + "GB"}
+	for i := 1; i < len(histogramBoundsGB); i++ {
+		labels = append(labels, strconv.Itoa(histogramBoundsGB[i-1])+"-"+strconv.Itoa(histogramBoundsGB[i])+"GB")
+	}
+	return append(labels, ">"+strconv.Itoa(histogramBoundsGB[len(histogramBoundsGB)-1])+"GB")
+}
+
+func getHistogramBucket(shardSize int64) int {
+	for i, bound := range histogramBoundsGB {
+		if shardSize < int64(bound)<<30 {
+			return i
+		}
+	}
+	return len(histogramBoundsGB)
+}
+
+// getShardSizeHistogram counts the primary shards of the indices by size, with their current and
+// their proposed primaries
+func getShardSizeHistogram(rows []IndexRow) (current, proposed []int) {
+	current = make([]int, len(histogramBoundsGB)+1)
+	proposed = make([]int, len(histogramBoundsGB)+1)
+	for _, row := range rows {
+		if row.Primaries > 0 {
+			current[getHistogramBucket(row.PrimarySizeInBytes/int64(row.Primaries))] += row.Primaries
+		}
+		if row.PotentialPrimaries > 0 {
+			proposed[getHistogramBucket(row.PrimarySizeInBytes/int64(row.PotentialPrimaries))] += row.PotentialPrimaries
+		}
+	}
+	return
+}
+
+// drawShardSizeHistogram draws the current and the proposed primaries per shard size bucket side by side
+func drawShardSizeHistogram(rows []IndexRow, palette chartPalette, p printer) image.Image {
+	current, proposed := getShardSizeHistogram(rows)
+	labels := getHistogramLabels()
+	height := 520
+	c := newCanvas(chartWidth, height)
+	c.drawText(20, 16, p.Sprintf("chart.histogram"), chartScale, chartText)
+	drawLegend(c, chartWidth-20, 16, []string{p.Sprintf("chart.current"), p.Sprintf("chart.proposed")}, []color.RGBA{palette.primary, chartGreen})
+
+	left, top, bottom, right := 100, 70, height-50, chartWidth-20
+	maxCount := 0
+	for i := range current {
+		if current[i] > maxCount {
+			maxCount = current[i]
+		}
+		if proposed[i] > maxCount {
+			maxCount = proposed[i]
+		}
+	}
+	axisMax, step := getNiceAxis(maxCount)
+	for value := 0; value <= axisMax; value += step {
+		y := bottom - (bottom-top)*value/axisMax
+		c.fillRect(left, y, right, y+1, chartGrid)
+		label := strconv.Itoa(value)
+		c.drawText(left-10-textWidth(label, chartScale), y-glyphHeight*chartScale/2, label, chartScale, chartText)
+	}
+	groupWidth := (right - left) / len(labels)
+	barWidth := groupWidth * 3 / 8
+	for i, label := range labels {
+		x := left + i*groupWidth + (groupWidth-2*barWidth)/2
+		for j, count := range []int{current[i], proposed[i]} {
+			barTop := bottom - (bottom-top)*count/axisMax
+			col := palette.primary
+			if j == 1 {
+				col = chartGreen
+			}
+			c.fillRect(x+j*barWidth, barTop, x+(j+1)*barWidth-4, bottom, col)
+			if count > 0 {
+				text := strconv.Itoa(count)
+				c.drawText(x+j*barWidth+(barWidth-4-textWidth(text, chartScale))/2, barTop-lineHeight, text, chartScale, chartText)
+			}
+		}
+		c.drawText(left+i*groupWidth+(groupWidth-textWidth(label, chartScale))/2, bottom+14, label, chartScale, chartText)
+	}
+	c.fillRect(left, bottom, right, bottom+2, chartText)
+	return c
+}
+
+// maxChartNodes keeps the node chart on one page, larger clusters show their largest nodes
+const maxChartNodes = 40
+
+// drawNodeCharts draws the shards and the bytes of every node as bars of primaries and replicas
+func drawNodeCharts(nodes map[string]*models.NodeStats, palette chartPalette, p printer) image.Image {
+	names := getNodeNamesBySize(nodes)
+	title := p.Sprintf("chart.nodes")
+	if len(names) > maxChartNodes {
+		names = names[:maxChartNodes]
+		title = p.Sprintf("chart.largestNodes", maxChartNodes, len(nodes))
+	}
+	rowHeight := 28
+	top := 70
+	height := top + len(names)*rowHeight + 20
+	c := newCanvas(chartWidth, height)
+	c.drawText(20, 16, title, chartScale, chartText)
+	drawLegend(c, chartWidth-20, 16, []string{p.Sprintf("chart.primary"), p.Sprintf("chart.replica")}, []color.RGBA{palette.primary, palette.accent})
+
+	labelWidth := 300
+	panelWidth := (chartWidth - labelWidth - 40) / 2
+	var maxShards, maxBytes int64
+	for _, name := range names {
+		ns := nodes[name]
+		if shards := int64(ns.PrimaryShardsCount + ns.ReplicaShardsCount); shards > maxShards {
+			maxShards = shards
+		}
+		if bytes := ns.PrimarySizeBytes + ns.ReplicaSizeBytes; bytes > maxBytes {
+			maxBytes = bytes
+		}
+	}
+	// bars end before the right of the panel to leave room for their value
+	barSpace := panelWidth - 140
+	for i, name := range names {
+		ns := nodes[name]
+		y := top + i*rowHeight
+		if i%2 == 0 {
+			c.fillRect(0, y-4, chartWidth, y+rowHeight-4, palette.stripe)
+		}
+		c.drawText(20, y+(rowHeight-8-glyphHeight*chartScale)/2, fitText(ns.NodeName, labelWidth-30, chartScale), chartScale, chartText)
+		panels := []struct {
+			primary, replica, max int64
+			label                 string
+		}{
+			{int64(ns.PrimaryShardsCount), int64(ns.ReplicaShardsCount), maxShards, strconv.Itoa(ns.PrimaryShardsCount + ns.ReplicaShardsCount)},
+			{ns.PrimarySizeBytes, ns.ReplicaSizeBytes, maxBytes, p.bytes(ns.PrimarySizeBytes + ns.ReplicaSizeBytes)},
+		}
+		for j, panel := range panels {
+			x := labelWidth + j*(panelWidth+40)
+			primaryWidth, replicaWidth := 0, 0
+			if panel.max > 0 {
+				primaryWidth = int(int64(barSpace) * panel.primary / panel.max)
+				replicaWidth = int(int64(barSpace)*(panel.primary+panel.replica)/panel.max) - primaryWidth
+			}
+			c.fillRect(x, y, x+primaryWidth, y+rowHeight-8, palette.primary)
+			c.fillRect(x+primaryWidth, y, x+primaryWidth+replicaWidth, y+rowHeight-8, palette.accent)
+			c.drawText(x+primaryWidth+replicaWidth+8, y+(rowHeight-8-glyphHeight*chartScale)/2, panel.label, chartScale, chartText)
+		}
+	}
+	return c
+}
+
+// treemapItems is the number of patterns shown in the treemap, smaller ones are merged into one
+const treemapItems = 12
+
+type treemapItem struct {
+	label string
+	size  int64
+}
+
+// getStorageByPattern returns the primary bytes of the patterns, largest first and the smallest
+// merged as otherLabel
+func getStorageByPattern(rows []IndexRow, otherLabel string) (items []treemapItem) {
+	sizes := make(map[string]int64)
+	for _, row := range rows {
+		sizes[row.Pattern] += row.PrimarySizeInBytes
+	}
+	for pattern, size := range sizes {
+		if size > 0 {
+			items = append(items, treemapItem{pattern, size})
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].size != items[j].size {
+			return items[i].size > items[j].size
+		}
+		return items[i].label < items[j].label
+	})
+	if len(items) > treemapItems {
+		other := treemapItem{label: otherLabel}
+		for _, item := range items[treemapItems-1:] {
+			other.size += item.size
+		}
+		items = append(items[:treemapItems-1], other)
+	}
+	return
+}
+
+// drawStorageTreemap draws the primary storage of the patterns as a squarified treemap
+func drawStorageTreemap(rows []IndexRow, palette chartPalette, p printer) image.Image {
+	items := getStorageByPattern(rows, p.Sprintf("chart.other"))
+	height := 600
+	c := newCanvas(chartWidth, height)
+	c.drawText(20, 16, p.Sprintf("chart.storage"), chartScale, chartText)
+	values := make([]float64, len(items))
+	for i, item := range items {
+		values[i] = float64(item.size)
+	}
+	for i, r := range layoutTreemap(values, 20, 50, chartWidth-40, float64(height-70)) {
+		x0, y0 := int(math.Round(r.x)), int(math.Round(r.y))
+		x1, y1 := int(math.Round(r.x+r.w)), int(math.Round(r.y+r.h))
+		col := palette.shades[i%len(palette.shades)]
+		c.fillRect(x0, y0, x1, y1, col)
+		c.strokeRect(x0, y0, x1, y1, chartWhite)
+		// dark tiles get white text, light tiles dark text
+		textColor := chartWhite
+		if i%len(palette.shades) >= len(palette.shades)/2 {
+			textColor = chartText
+		}
+		if x1-x0 > 30 && y1-y0 > 2*lineHeight+10 {
+			c.drawText(x0+8, y0+8, fitText(items[i].label, x1-x0-16, chartScale), chartScale, textColor)
+			c.drawText(x0+8, y0+8+lineHeight, fitText(p.bytes(items[i].size), x1-x0-16, chartScale), chartScale, textColor)
+		}
+	}
+	return c
+}
+
+type rectangle struct {
+	x, y, w, h float64
+}
+
+// layoutTreemap splits the rectangle into one per value, in the order of the values which should
+// be sorted largest first. Rows are added to while that keeps their tiles closer to squares.
+func layoutTreemap(values []float64, x, y, w, h float64) []rectangle {
+	rects := make([]rectangle, len(values))
+	total := 0.0
+	for _, v := range values {
+		total += v
+	}
+	if total <= 0 {
+		return rects
+	}
+	areas := make([]float64, len(values))
+	for i, v := range values {
+		areas[i] = v * w * h / total
+	}
+	for i := 0; i < len(areas); {
+		side := math.Min(w, h)
+		j := i + 1
+		for j < len(areas) && worstRatio(areas[i:j+1], side) <= worstRatio(areas[i:j], side) {
+			j++
+		}
+		rowArea := 0.0
+		for _, a := range areas[i:j] {
+			rowArea += a
+		}
+		if w >= h {
+			// a column on the left
+			columnWidth := rowArea / h
+			top := y
+			for k, a := range areas[i:j] {
+				rects[i+k] = rectangle{x, top, columnWidth, a / columnWidth}
+				top += a / columnWidth
+			}
+			x, w = x+columnWidth, w-columnWidth
+		} else {
+			// a row on the top
+			rowHeight := rowArea / w
+			left := x
+			for k, a := range areas[i:j] {
+				rects[i+k] = rectangle{left, y, a / rowHeight, rowHeight}
+				left += a / rowHeight
+			}
+			y, h = y+rowHeight, h-rowHeight
+		}
+		i = j
+	}
+	return rects
+}
+
+// worstRatio returns the largest aspect ratio of the tiles of a row along a side
+func worstRatio(row []float64, side float64) float64 {
+	sum, largest, smallest := 0.0, 0.0, math.MaxFloat64
+	for _, a := range row {
+		sum += a
+		largest = math.Max(largest, a)
+		smallest = math.Min(smallest, a)
+	}
+	return math.Max(side*side*largest/(sum*sum), sum*sum/(side*side*smallest))
+}
+
+// drawLegend draws the labels with their color from right to left, ending at right
+func drawLegend(c canvas, right, y int, labels []string, colors []color.RGBA) {
+	x := right
+	for i := len(labels) - 1; i >= 0; i-- {
+		x -= textWidth(labels[i], chartScale)
+		c.drawText(x, y, labels[i], chartScale, chartText)
+		x -= glyphHeight*chartScale + 8
+		c.fillRect(x, y, x+glyphHeight*chartScale, y+glyphHeight*chartScale, colors[i])
+		x -= 30
+	}
+}
+
+// getNiceAxis returns the top of an axis from zero and a step of 1, 2 or 5 times a power of ten
+// that divides it into at most 5 parts
+func getNiceAxis(maxValue int) (axisMax, step int) {
+	if maxValue <= 0 {
+		return 1, 1
+	}
+	for power := 1; ; power *= 10 {
+		for _, factor := range []int{1, 2, 5} {
+			step = factor * power
+			if axisMax = (maxValue + step - 1) / step * step; axisMax/step <= 5 {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package reports
+
+import (
+	"image/color"
+	"math"
+	"shardanalyzer/models"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/language"
+)
+
+func Test_getShardSizeHistogram(t *testing.T) {
+	rows := []IndexRow{
+		{Pattern: "logs-*", Primaries: 10, PotentialPrimaries: 1, PrimarySizeInBytes: 20 << 30},
+		{Pattern: "small", Primaries: 1, PotentialPrimaries: 1, PrimarySizeInBytes: 100 << 20},
+	}
+	current, proposed := getShardSizeHistogram(rows)
+	// 10 shards of 2GB become one of 20GB
+	assert.Equal(t, []int{1, 10, 0, 0, 0, 0}, current)
+	assert.Equal(t, []int{1, 0, 0, 1, 0, 0}, proposed)
+	assert.Equal(t, []string{"<1GB", "1-5GB", "5-10GB", "10-30GB", "30-50GB", ">50GB"}, getHistogramLabels())
+}
+
+func Test_layoutTreemap(t *testing.T) {
+	values := []float64{6, 6, 4, 3, 2, 2, 1}
+	rects := layoutTreemap(values, 10, 20, 600, 400)
+	total := 0.0
+	for i, r := range rects {
+		// tiles keep the share of their value and stay inside the rectangle
+		assert.InDelta(t, values[i]/24*600*400, r.w*r.h, 0.001)
+		assert.True(t, r.x >= 10-1e-9 && r.y >= 20-1e-9 && r.x+r.w <= 610+1e-9 && r.y+r.h <= 420+1e-9, r)
+		total += r.w * r.h
+	}
+	assert.InDelta(t, 600*400, total, 0.001)
+	// the first row is two tiles of 6 next to each other on the left
+	assert.InDelta(t, rects[0].x, rects[1].x, 1e-9)
+	assert.InDelta(t, 1.0, math.Max(rects[0].w/rects[0].h, rects[0].h/rects[0].w), 0.5)
+}
+
+func Test_getStorageByPattern(t *testing.T) {
+	var rows []IndexRow
+	for i := 0; i < treemapItems+3; i++ {
+		rows = append(rows, IndexRow{Pattern: "pattern-" + strconv.Itoa(i), PrimarySizeInBytes: int64(100 - i)})
+	}
+	items := getStorageByPattern(rows, "Other")
+	assert.Len(t, items, treemapItems)
+	assert.Equal(t, treemapItem{"pattern-0", 100}, items[0])
+	assert.Equal(t, treemapItem{"Other", 89 + 88 + 87 + 86}, items[treemapItems-1])
+}
+
+func Test_getNiceAxis(t *testing.T) {
+	for maxValue, expected := range map[int][2]int{0: {1, 1}, 3: {3, 1}, 7: {8, 2}, 12: {15, 5}, 70: {80, 20}, 480: {500, 100}} {
+		axisMax, step := getNiceAxis(maxValue)
+		assert.Equal(t, expected, [2]int{axisMax, step}, maxValue)
+	}
+}
+
+func Test_drawText(t *testing.T) {
+	c := newCanvas(40, 20)
+	black := color.RGBA{0, 0, 0, 255}
+	c.drawText(1, 1, "i", 1, black)
+	// the top row of I is 01110
+	assert.Equal(t, black, c.RGBAAt(2, 1))
+	assert.Equal(t, color.RGBA{255, 255, 255, 255}, c.RGBAAt(1, 1))
+	assert.Equal(t, 11*2, textWidth("ab", 2))
+	assert.Equal(t, "node-..", fitText("node-1.example", textWidth("node-..", 1), 1))
+}
+
+func Test_drawNodeCharts(t *testing.T) {
+	nodes := make(map[string]*models.NodeStats)
+	for i := 0; i < maxChartNodes+5; i++ {
+		name := "node-" + strconv.Itoa(i)
+		nodes[name] = &models.NodeStats{NodeName: name, PrimaryShardsCount: i, PrimarySizeBytes: int64(i) << 30}
+	}
+	img := drawNodeCharts(nodes, defaultPalette, newPrinter(language.English))
+	// only the largest nodes are drawn so the chart fits on a page
+	assert.Equal(t, chartWidth, img.Bounds().Dx())
+	assert.Equal(t, 70+maxChartNodes*28+20, img.Bounds().Dy())
+}
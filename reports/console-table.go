@@ -5,12 +5,18 @@ import (
 	"github.com/olekukonko/tablewriter"
 	"shardanalyzer/models"
 	"strconv"
+
+	"golang.org/x/text/language"
 )
 
 func RenderAsTable(recommendation models.Recommendation) string {
+	return renderAsTable(recommendation, newPrinter(language.English))
+}
+
+func renderAsTable(recommendation models.Recommendation, p printer) string {
 	var buf bytes.Buffer
 	table := tablewriter.NewWriter(&buf)
-	table.SetHeader([]string{"Index Name", "Primary Size", "Current Settings", "Recommended Settings"})
+	table.SetHeader([]string{p.Sprintf("column.index"), p.Sprintf("column.primarySize"), p.Sprintf("column.currentSettings"), p.Sprintf("column.recommendedSettings")})
 	table.SetHeaderColor(tablewriter.Colors{tablewriter.Bold},
 		tablewriter.Colors{tablewriter.Bold, tablewriter.Bold},
 		tablewriter.Colors{tablewriter.Bold},
@@ -27,11 +33,11 @@ func RenderAsTable(recommendation models.Recommendation) string {
 		{tablewriter.Bold, tablewriter.FgHiGreenColor}}
 	for _, ipr := range recommendation.IndexPatternRecommendationRollup {
 		if ipr.NeedChanges {
-			if ipr.IsIndependentIndexPattern() || len(ipr.Indices) > 1 {
-				patternData := []string{"IndexPattern=" + ipr.Pattern}
+			if ipr.IsIndependentIndexPattern() || ipr.IsDataStream() || len(ipr.Indices) > 1 {
+				patternData := []string{p.Sprintf("pattern.console", ipr.Pattern)}
 				table.Rich(patternData, []tablewriter.Colors{{tablewriter.Normal, tablewriter.ALIGN_CENTER, tablewriter.BgGreenColor, tablewriter.FgBlackColor}, {tablewriter.Normal, tablewriter.BgGreenColor, tablewriter.FgBlackColor}, {tablewriter.Normal, tablewriter.BgGreenColor, tablewriter.FgBlackColor}})
 				for _, ir := range ipr.Indices {
-					rowData := []string{ir.Name, ByteCountIEC(ir.PrimarySizeInBytes), strconv.Itoa(ir.Primaries) + "/" + strconv.Itoa(ir.Replicas/ir.Primaries), strconv.Itoa(ir.PotentialPrimaries) + "/" + strconv.Itoa(ir.PotentialReplicas)}
+					rowData := []string{ir.Name, p.bytes(ir.PrimarySizeInBytes), strconv.Itoa(ir.Primaries) + "/" + strconv.Itoa(ir.Replicas/ir.Primaries), strconv.Itoa(ir.PotentialPrimaries) + "/" + strconv.Itoa(ir.PotentialReplicas)}
 					if ir.Primaries == ir.PotentialPrimaries && ir.Replicas == ir.PotentialReplicas {
 						table.Rich(rowData, noChangesNeeded)
 					} else {
@@ -43,12 +49,25 @@ func RenderAsTable(recommendation models.Recommendation) string {
 
 	}
 
-	table.SetFooter([]string{"", "", strconv.Itoa(recommendation.TotalShards), strconv.Itoa(recommendation.PotentialShards)}) // Add Footer
+	table.SetFooter([]string{"", "", p.number(int64(recommendation.TotalShards)), p.number(int64(recommendation.PotentialShards))}) // Add Footer
 	//table.SetRowLine(true)
 	//table.SetBorders(tablewriter.Border{Left: true, Top: false, Right: true, Bottom: false})
 	//table.SetCenterSeparator("|")
 	//table.SetAutoMergeCells(true)
 	table.SetAutoFormatHeaders(true)
 	table.Render()
+	renderFindingsTable(&buf, recommendation.Findings, p)
 	return buf.String()
 }
+
+func renderFindingsTable(buf *bytes.Buffer, findings []models.Finding, p printer) {
+	if len(findings) == 0 {
+		return
+	}
+	buf.WriteString("\n")
+	table := tablewriter.NewWriter(buf)
+	table.SetHeader(getFindingHeader(p))
+	table.SetAutoWrapText(true)
+	table.AppendBulk(getFindingRows(findings, p))
+	table.Render()
+}
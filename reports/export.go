@@ -0,0 +1,135 @@
+package reports
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"shardanalyzer/models"
+	"strconv"
+)
+
+// IndexRow is an index of the recommendation flattened for spreadsheets, sizes stay in bytes so
+// they sort as numbers.
+type IndexRow struct {
+	Pattern             string
+	Index               string
+	Primaries           int
+	Replicas            int // replicas per primary, like number_of_replicas
+	PotentialPrimaries  int
+	PotentialReplicas   int
+	PrimarySizeInBytes  int64
+	Docs                int64 // of primaries and replicas, like IndexRecommendation.Docs
+	AvgShardSizeInBytes int64
+	NeedsChange         bool
+}
+
+var indexColumns = []string{"Pattern", "Index", "Primaries", "Replicas", "Potential Primaries", "Potential Replicas", "Primary Size (bytes)", "Docs (with replicas)", "Average Shard Size (bytes)", "Needs Change"}
+
+// GetIndexRows returns a row per index of IndexPatternRecommendationRollup. Indices moved to the
+// single index patterns by MergeSingleIndexPatterns keep the first pattern they were found in.
+func GetIndexRows(recommendation models.Recommendation) (rows []IndexRow) {
+	seen := make(map[string]bool)
+	for _, ipr := range recommendation.IndexPatternRecommendationRollup {
+		for _, ir := range ipr.Indices {
+			if seen[ir.Name] {
+				continue
+			}
+			seen[ir.Name] = true
+			row := IndexRow{
+				Pattern:            ipr.Pattern,
+				Index:              ir.Name,
+				Primaries:          ir.Primaries,
+				PotentialPrimaries: ir.PotentialPrimaries,
+				PotentialReplicas:  ir.PotentialReplicas,
+				PrimarySizeInBytes: ir.PrimarySizeInBytes,
+				Docs:               ir.Docs,
+			}
+			if ir.Primaries > 0 {
+				row.Replicas = ir.Replicas / ir.Primaries
+				row.AvgShardSizeInBytes = ir.PrimarySizeInBytes / int64(ir.Primaries)
+			}
+			row.NeedsChange = row.Primaries != row.PotentialPrimaries || row.Replicas != row.PotentialReplicas
+			rows = append(rows, row)
+		}
+	}
+	return
+}
+
+func (row IndexRow) cells() []interface{} {
+	return []interface{}{row.Pattern, row.Index, row.Primaries, row.Replicas, row.PotentialPrimaries, row.PotentialReplicas, row.PrimarySizeInBytes, row.Docs, row.AvgShardSizeInBytes, row.NeedsChange}
+}
+
+// sheet is a table of the export, cells are strings, integers, floats or bools
+type sheet struct {
+	Name   string
+	Header []string
+	Rows   [][]interface{}
+}
+
+func getIndexSheet(recommendation models.Recommendation) sheet {
+	s := sheet{Name: "Indices", Header: indexColumns}
+	for _, row := range GetIndexRows(recommendation) {
+		s.Rows = append(s.Rows, row.cells())
+	}
+	return s
+}
+
+func getNodeSheet(nodes map[string]*models.NodeStats) sheet {
+	s := sheet{Name: "Nodes", Header: []string{"Node", "Primary Shards", "Replica Shards", "Primary Size (bytes)", "Replica Size (bytes)"}}
+	for _, name := range getNodeNamesBySize(nodes) {
+		ns := nodes[name]
+		s.Rows = append(s.Rows, []interface{}{ns.NodeName, ns.PrimaryShardsCount, ns.ReplicaShardsCount, ns.PrimarySizeBytes, ns.ReplicaSizeBytes})
+	}
+	return s
+}
+
+func getFindingSheet(findings []models.Finding) sheet {
+	s := sheet{Name: "Findings", Header: []string{"Severity", "Type", "Index", "Pattern", "Finding", "Remediation"}}
+	for _, f := range findings {
+		s.Rows = append(s.Rows, []interface{}{string(f.Severity), string(f.Type), f.Index, f.Pattern, f.Message, f.Remediation})
+	}
+	return s
+}
+
+// CSVRenderer writes the rows of GetIndexRows as CSV.
+type CSVRenderer struct{}
+
+func (CSVRenderer) ContentType() string {
+	return ContentTypeCSV
+}
+
+func (CSVRenderer) Render(w io.Writer, recommendation models.Recommendation, nodes map[string]*models.NodeStats) error {
+	s := getIndexSheet(recommendation)
+	out := csv.NewWriter(w)
+	out.Write(s.Header)
+	record := make([]string, len(s.Header))
+	for _, row := range s.Rows {
+		for i, cell := range row {
+			record[i] = formatCell(cell)
+		}
+		out.Write(record)
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// XLSXRenderer writes a workbook with a sheet each for the indices, the nodes and the findings.
+type XLSXRenderer struct{}
+
+func (XLSXRenderer) ContentType() string {
+	return ContentTypeXLSX
+}
+
+func (XLSXRenderer) Render(w io.Writer, recommendation models.Recommendation, nodes map[string]*models.NodeStats) error {
+	return writeXLSX(w, []sheet{getIndexSheet(recommendation), getNodeSheet(nodes), getFindingSheet(recommendation.Findings)})
+}
+
+func formatCell(cell interface{}) string {
+	switch value := cell.(type) {
+	case string:
+		return value
+	case float64:
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}
+	return fmt.Sprint(cell)
+}
@@ -0,0 +1,81 @@
+package reports
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_getIndexRows(t *testing.T) {
+	recommendation, _ := getTestCluster(t, catShards, "test")
+	rows := GetIndexRows(recommendation)
+	assert.Len(t, rows, 2)
+	assert.Equal(t, IndexRow{
+		Pattern:             "logs-****.**.**",
+		Index:               "logs-2023.01.01",
+		Primaries:           2,
+		Replicas:            1,
+		PotentialPrimaries:  1,
+		PotentialReplicas:   1,
+		PrimarySizeInBytes:  2147483648,
+		Docs:                4000000,
+		AvgShardSizeInBytes: 1073741824,
+		NeedsChange:         true,
+	}, rows[0])
+}
+
+func Test_csvRenderer(t *testing.T) {
+	recommendation, nodes := getTestCluster(t, catShards, "test")
+	var buf bytes.Buffer
+	assert.NoError(t, CSVRenderer{}.Render(&buf, recommendation, nodes))
+	records, err := csv.NewReader(&buf).ReadAll()
+	assert.NoError(t, err)
+	assert.Len(t, records, 3)
+	assert.Equal(t, indexColumns, records[0])
+	assert.Equal(t, []string{"logs-****.**.**", "logs-2023.01.01", "2", "1", "1", "1", "2147483648", "4000000", "1073741824", "true"}, records[1])
+}
+
+func Test_xlsxRenderer(t *testing.T) {
+	recommendation, nodes := getTestCluster(t, catShards, "test")
+	var buf bytes.Buffer
+	assert.NoError(t, XLSXRenderer{}.Render(&buf, recommendation, nodes))
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.NoError(t, err)
+
+	files := make(map[string]string)
+	for _, f := range archive.File {
+		r, err := f.Open()
+		assert.NoError(t, err)
+		data, _ := io.ReadAll(r)
+		files[f.Name] = string(data)
+		// every part is well-formed XML
+		decoder := xml.NewDecoder(bytes.NewReader(data))
+		for err == nil {
+			_, err = decoder.Token()
+		}
+		assert.Equal(t, io.EOF, err, f.Name)
+	}
+	assert.Contains(t, files, "[Content_Types].xml")
+	assert.Contains(t, files["xl/workbook.xml"], `<sheet name="Indices" sheetId="1" r:id="rId1"/><sheet name="Nodes" sheetId="2" r:id="rId2"/><sheet name="Findings" sheetId="3" r:id="rId3"/>`)
+	indices := files["xl/worksheets/sheet1.xml"]
+	assert.Contains(t, indices, `<c r="B2" t="inlineStr"><is><t xml:space="preserve">logs-2023.01.01</t></is></c>`)
+	assert.Contains(t, indices, `<c r="G2"><v>2147483648</v></c>`)
+	assert.Contains(t, indices, `<c r="J2" t="b"><v>1</v></c>`)
+	assert.Contains(t, indices, `<autoFilter ref="A1:J3"/>`)
+	assert.True(t, strings.Contains(files["xl/worksheets/sheet2.xml"], "node-1"))
+	// no findings still leaves the header
+	assert.Contains(t, files["xl/worksheets/sheet3.xml"], `<autoFilter ref="A1:F1"/>`)
+}
+
+func Test_getXLSXColumn(t *testing.T) {
+	assert.Equal(t, "A", getXLSXColumn(0))
+	assert.Equal(t, "Z", getXLSXColumn(25))
+	assert.Equal(t, "AA", getXLSXColumn(26))
+	assert.Equal(t, "BA", getXLSXColumn(52))
+}
@@ -0,0 +1,54 @@
+package reports
+
+import (
+	"embed"
+	"fmt"
+	"html/template"
+	"io"
+	"shardanalyzer/models"
+	"time"
+
+	"golang.org/x/text/language"
+)
+
+//go:embed templates
+var templateFS embed.FS
+
+// the report is a single file, its stylesheet and script are inlined
+var (
+	htmlTemplate = template.Must(template.New("report.html").Funcs(template.FuncMap{"t": fmt.Sprintf}).ParseFS(templateFS, "templates/report.html"))
+	htmlCSS      = template.CSS(mustReadTemplateFile("templates/report.css"))
+	htmlJS       = template.JS(mustReadTemplateFile("templates/report.js"))
+)
+
+// HTMLRenderer writes a self-contained HTML report with sortable tables and a collapsible section
+// per index pattern.
+type HTMLRenderer struct {
+	Locale language.Tag     // English when unset
+	Now    func() time.Time // time.Now when nil
+}
+
+func (HTMLRenderer) ContentType() string {
+	return ContentTypeHTML
+}
+
+func (h HTMLRenderer) Render(w io.Writer, recommendation models.Recommendation, nodes map[string]*models.NodeStats) error {
+	p := newPrinter(h.Locale)
+	tmpl, err := htmlTemplate.Clone()
+	if err != nil {
+		return err
+	}
+	return tmpl.Funcs(template.FuncMap{"t": p.Sprintf}).Execute(w, struct {
+		report
+		CSS template.CSS
+		JS  template.JS
+	}{newReport(recommendation, nodes, h.Locale, getNow(h.Now)), htmlCSS, htmlJS})
+}
+
+func mustReadTemplateFile(name string) string {
+	data, err := templateFS.ReadFile(name)
+	if err != nil {
+		panic(err)
+	}
+	return string(data)
+}
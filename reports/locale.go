@@ -0,0 +1,96 @@
+package reports
+
+import (
+	"embed"
+	"encoding/json"
+	"path"
+	"strings"
+	"time"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/message/catalog"
+)
+
+// the message catalogs, a JSON object of message keys and fmt formats per locale
+//
+//go:embed locales
+var localeFS embed.FS
+
+// Locales are the languages of the reports, English is the fallback for missing messages.
+var Locales = []language.Tag{language.English, language.German}
+
+var (
+	localeMatcher  = language.NewMatcher(Locales)
+	localeCatalogs = mustLoadCatalogs()
+)
+
+func mustLoadCatalogs() catalog.Catalog {
+	builder := catalog.NewBuilder(catalog.Fallback(language.English))
+	for _, tag := range Locales {
+		data, err := localeFS.ReadFile(path.Join("locales", tag.String()+".json"))
+		if err != nil {
+			panic(err)
+		}
+		var messages map[string]string
+		if err = json.Unmarshal(data, &messages); err != nil {
+			panic(err)
+		}
+		for key, msg := range messages {
+			if err = builder.SetString(tag, key, msg); err != nil {
+				panic(err)
+			}
+		}
+	}
+	return builder
+}
+
+// MatchLocale returns the locale closest to the first preference that names a language, like "de"
+// or an Accept-Language header. Without one it returns English.
+func MatchLocale(preferences ...string) language.Tag {
+	for _, preference := range preferences {
+		if strings.TrimSpace(preference) == "" {
+			continue
+		}
+		tags, _, err := language.ParseAcceptLanguage(preference)
+		if err != nil || len(tags) == 0 {
+			continue
+		}
+		_, index, _ := localeMatcher.Match(tags...)
+		return Locales[index]
+	}
+	return language.English
+}
+
+// printer translates the messages of a locale and formats numbers, sizes and dates like it
+type printer struct {
+	*message.Printer
+	tag language.Tag
+}
+
+func newPrinter(locale language.Tag) printer {
+	_, index, _ := localeMatcher.Match(locale)
+	return printer{message.NewPrinter(Locales[index], message.Catalog(localeCatalogs)), Locales[index]}
+}
+
+// bytes is ByteCountIEC with the decimal separator of the locale
+func (p printer) bytes(b int64) string {
+	const unit = 1024
+	if b < unit {
+		return p.Sprintf("%d B", b)
+	}
+	div, exp := int64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return p.Sprintf("%.1f", float64(b)/float64(div)) + " " + string("KMGTPE"[exp]) + "B"
+}
+
+func (p printer) number(n int64) string {
+	return p.Sprintf("%d", n)
+}
+
+func (p printer) date(t time.Time) string {
+	return t.Format(p.Sprintf("date.layout"))
+}
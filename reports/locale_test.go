@@ -0,0 +1,57 @@
+package reports
+
+import (
+	"encoding/json"
+	"path"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/language"
+)
+
+func Test_matchLocale(t *testing.T) {
+	assert.Equal(t, language.German, MatchLocale("de-AT,en;q=0.5"))
+	assert.Equal(t, language.German, MatchLocale("", "de"))
+	assert.Equal(t, language.English, MatchLocale("fr"))
+	assert.Equal(t, language.English, MatchLocale())
+	// the locale of the request wins over the Accept-Language header
+	assert.Equal(t, language.English, MatchLocale("en", "de"))
+}
+
+func Test_localeCatalogs(t *testing.T) {
+	verbs := regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z]`)
+	messages := map[language.Tag]map[string]string{}
+	for _, tag := range Locales {
+		data, err := localeFS.ReadFile(path.Join("locales", tag.String()+".json"))
+		assert.NoError(t, err)
+		var catalog map[string]string
+		assert.NoError(t, json.Unmarshal(data, &catalog))
+		messages[tag] = catalog
+	}
+	// every locale translates every message with the same arguments
+	for _, tag := range Locales[1:] {
+		assert.Len(t, messages[tag], len(messages[language.English]), tag.String())
+		for key, msg := range messages[language.English] {
+			translated, ok := messages[tag][key]
+			if assert.True(t, ok, "%s misses %s", tag, key) {
+				assert.Equal(t, verbs.FindAllString(strings.ReplaceAll(msg, "%%", ""), -1), verbs.FindAllString(strings.ReplaceAll(translated, "%%", ""), -1), "%s %s", tag, key)
+			}
+		}
+	}
+}
+
+func Test_printer(t *testing.T) {
+	de := newPrinter(language.German)
+	assert.Equal(t, "2,0 GB", de.bytes(2147483648))
+	assert.Equal(t, "512 B", de.bytes(512))
+	assert.Equal(t, "1.000.000", de.number(1000000))
+	assert.Equal(t, "31.01.2023", de.date(time.Date(2023, 1, 31, 0, 0, 0, 0, time.UTC)))
+
+	en := newPrinter(language.Make("en-GB"))
+	assert.Equal(t, language.English, en.tag)
+	assert.Equal(t, "2.0 GB", en.bytes(2147483648))
+	assert.Equal(t, "1,000,000", en.number(1000000))
+}
@@ -0,0 +1,88 @@
+package reports
+
+import (
+	"bufio"
+	"io"
+	"shardanalyzer/models"
+	"strings"
+	"time"
+
+	"golang.org/x/text/language"
+)
+
+// MarkdownRenderer writes the report as GitHub flavoured Markdown, for tickets and wikis.
+type MarkdownRenderer struct {
+	Locale language.Tag     // English when unset
+	Now    func() time.Time // time.Now when nil
+}
+
+func (MarkdownRenderer) ContentType() string {
+	return ContentTypeMarkdown
+}
+
+func (md MarkdownRenderer) Render(w io.Writer, recommendation models.Recommendation, nodes map[string]*models.NodeStats) error {
+	p := newPrinter(md.Locale)
+	r := newReport(recommendation, nodes, md.Locale, getNow(md.Now))
+	attributes := []string{p.Sprintf("column.attribute"), p.Sprintf("column.value")}
+	out := bufio.NewWriter(w)
+	out.WriteString("# " + escapeMarkdown(r.Title) + "\n\n")
+	out.WriteString("_" + r.Generated + ", " + escapeMarkdown(r.BuildStr) + "_\n\n")
+
+	out.WriteString("## " + p.Sprintf("heading.summary") + "\n\n")
+	for _, sentence := range r.Summary {
+		out.WriteString("- " + escapeMarkdown(sentence) + "\n")
+	}
+	out.WriteString("\n## " + p.Sprintf("heading.cluster") + "\n\n")
+	writeMarkdownTable(out, table{Header: attributes, Rows: r.Cluster})
+	if len(r.Findings.Rows) > 0 {
+		out.WriteString("## " + p.Sprintf("heading.findings") + "\n\n")
+		writeMarkdownTable(out, r.Findings)
+	}
+	if len(r.Patterns) > 0 {
+		out.WriteString("## " + p.Sprintf("heading.patterns") + "\n\n")
+	}
+	for _, pattern := range r.Patterns {
+		out.WriteString("### " + escapeMarkdown(pattern.Pattern) + "\n\n")
+		if len(pattern.Attributes) > 0 {
+			writeMarkdownTable(out, table{Header: attributes, Rows: pattern.Attributes})
+		}
+		writeMarkdownTable(out, pattern.Indices)
+	}
+	for _, s := range r.Sections {
+		out.WriteString("## " + escapeMarkdown(s.Title) + "\n\n")
+		writeMarkdownTable(out, s.Table)
+	}
+	out.WriteString("## " + p.Sprintf("heading.nodes") + "\n\n")
+	writeMarkdownTable(out, r.Nodes)
+	if len(r.Appendix) > 0 {
+		out.WriteString("## " + escapeMarkdown(p.Sprintf("heading.appendix")) + "\n\n")
+	}
+	for _, group := range r.Appendix {
+		out.WriteString("### " + group.Title + "\n\n```\n" + strings.Join(group.Commands, "\n") + "\n```\n\n")
+	}
+	return out.Flush()
+}
+
+func writeMarkdownTable(out *bufio.Writer, t table) {
+	writeMarkdownRow(out, t.Header)
+	out.WriteString("|" + strings.Repeat(" --- |", len(t.Header)) + "\n")
+	for _, row := range t.Rows {
+		writeMarkdownRow(out, row)
+	}
+	out.WriteString("\n")
+}
+
+func writeMarkdownRow(out *bufio.Writer, cells []string) {
+	out.WriteString("|")
+	for _, cell := range cells {
+		out.WriteString(" " + escapeMarkdown(cell) + " |")
+	}
+	out.WriteString("\n")
+}
+
+// pipes would end the cell and newlines the row, the stars of index patterns would start emphasis
+var markdownReplacer = strings.NewReplacer("|", `\|`, "\r\n", "<br>", "\n", "<br>", "*", `\*`)
+
+func escapeMarkdown(text string) string {
+	return markdownReplacer.Replace(text)
+}
@@ -0,0 +1,119 @@
+package reports
+
+import (
+	"mime"
+	"strconv"
+	"strings"
+)
+
+const (
+	ContentTypeJSON     = "application/json"
+	ContentTypePDF      = "application/pdf"
+	ContentTypeText     = "text/plain"
+	ContentTypeMarkdown = "text/markdown"
+	ContentTypeHTML     = "text/html"
+	ContentTypeCSV      = "text/csv"
+	ContentTypeXLSX     = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+)
+
+var supportedContentTypes = []string{ContentTypeJSON, ContentTypePDF, ContentTypeText, ContentTypeMarkdown, ContentTypeHTML, ContentTypeCSV, ContentTypeXLSX}
+
+// download names of the types that are saved rather than shown
+var fileNames = map[string]string{
+	ContentTypePDF:  "shard-analyzer-report.pdf",
+	ContentTypeCSV:  "shard-analyzer-indices.csv",
+	ContentTypeXLSX: "shard-analyzer-report.xlsx",
+}
+
+// content types of the -format flag of the command line
+var formats = map[string]string{
+	"json":     ContentTypeJSON,
+	"pdf":      ContentTypePDF,
+	"table":    ContentTypeText,
+	"markdown": ContentTypeMarkdown,
+	"html":     ContentTypeHTML,
+	"csv":      ContentTypeCSV,
+	"xlsx":     ContentTypeXLSX,
+}
+
+// NegotiateContentType picks the supported type the Accept header prefers, JSON when the header is
+// empty or accepts anything. As in RFC 9110 the most specific range that matches a type decides its
+// quality, and types of quality 0 are not acceptable. ok is false when none of the accepted types is
+// supported.
+func NegotiateContentType(accept string) (contentType string, ok bool) {
+	if strings.TrimSpace(accept) == "" {
+		return ContentTypeJSON, true
+	}
+	ranges := parseAccept(accept)
+	bestQuality, bestPosition := 0.0, len(ranges)
+	// wildcards match the supported types in their order, so */* picks JSON and text/* plain text
+	for _, supported := range supportedContentTypes {
+		position := matchMediaRange(ranges, supported)
+		if position < 0 {
+			continue
+		}
+		quality := ranges[position].quality
+		// the first of equally preferred types wins
+		if quality > bestQuality || quality == bestQuality && quality > 0 && position < bestPosition {
+			contentType, bestQuality, bestPosition = supported, quality, position
+		}
+	}
+	return contentType, contentType != ""
+}
+
+// GetFileName returns the name for the Content-Disposition of downloads.
+func GetFileName(contentType string) (string, bool) {
+	name, ok := fileNames[contentType]
+	return name, ok
+}
+
+// GetFormatContentType returns the content type of a format name like "pdf" or "table".
+func GetFormatContentType(format string) (string, bool) {
+	contentType, ok := formats[strings.ToLower(format)]
+	return contentType, ok
+}
+
+type mediaRange struct {
+	mediaType string
+	quality   float64
+}
+
+// parseAccept returns the ranges of the header in their order, invalid ones are left out
+func parseAccept(accept string) []mediaRange {
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(part)
+		if err != nil {
+			continue
+		}
+		quality := 1.0
+		if q, found := params["q"]; found {
+			if quality, err = strconv.ParseFloat(q, 64); err != nil || quality < 0 || quality > 1 {
+				continue
+			}
+		}
+		ranges = append(ranges, mediaRange{mediaType, quality})
+	}
+	return ranges
+}
+
+// matchMediaRange returns the position of the most specific range that matches the content type,
+// the first one of the same specificity, or -1 when none does
+func matchMediaRange(ranges []mediaRange, contentType string) int {
+	match, matchSpecificity := -1, 0
+	for i, r := range ranges {
+		specificity := 0
+		switch {
+		case r.mediaType == contentType:
+			specificity = 3
+		case strings.HasSuffix(r.mediaType, "/*") && strings.HasPrefix(contentType, strings.TrimSuffix(r.mediaType, "*")):
+			specificity = 2
+		case r.mediaType == "*/*":
+			specificity = 1
+		}
+		if specificity > matchSpecificity {
+			match, matchSpecificity = i, specificity
+		}
+	}
+	return match
+}
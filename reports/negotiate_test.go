@@ -0,0 +1,48 @@
+package reports
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_negotiateContentType(t *testing.T) {
+	cases := map[string]string{
+		"":                                    ContentTypeJSON,
+		"*/*":                                 ContentTypeJSON,
+		"application/pdf":                     ContentTypePDF,
+		"text/plain; charset=utf-8":           ContentTypeText,
+		"text/*":                              ContentTypeText,
+		"image/png, application/pdf;q=0.9":    ContentTypePDF,
+		"text/html,application/xhtml+xml":     ContentTypeHTML,
+		"text/markdown":                       ContentTypeMarkdown,
+		"application/json;q=0.5, text/plain":  ContentTypeText,
+		"application/pdf, application/json":   ContentTypePDF,
+		"image/png, */*;q=0.1":                ContentTypeJSON,
+		"application/pdf;q=0, text/plain;q=1": ContentTypeText,
+		// the most specific range decides, q=0 excludes the type
+		"application/json;q=0, */*":      ContentTypePDF,
+		"text/*;q=0.2, text/html":        ContentTypeHTML,
+		"text/plain;q=0, text/*":         ContentTypeMarkdown,
+		"*/*;q=0.1, application/*;q=0.5": ContentTypeJSON,
+	}
+	for accept, expected := range cases {
+		contentType, ok := NegotiateContentType(accept)
+		assert.True(t, ok, accept)
+		assert.Equal(t, expected, contentType, accept)
+	}
+	for _, accept := range []string{"image/png", "*/*;q=0", "application/pdf;q=2"} {
+		_, ok := NegotiateContentType(accept)
+		assert.False(t, ok, accept)
+	}
+}
+
+func Test_getFormatContentType(t *testing.T) {
+	contentType, ok := GetFormatContentType("PDF")
+	assert.True(t, ok)
+	assert.Equal(t, ContentTypePDF, contentType)
+	contentType, _ = GetFormatContentType("table")
+	assert.Equal(t, ContentTypeText, contentType)
+	_, ok = GetFormatContentType("docx")
+	assert.False(t, ok)
+}
@@ -1,71 +1,50 @@
 package reports
 
 import (
-	"bytes"
 	"fmt"
 	"github.com/johnfercher/maroto/pkg/color"
 	"github.com/johnfercher/maroto/pkg/consts"
 	"github.com/johnfercher/maroto/pkg/pdf"
 	"github.com/johnfercher/maroto/pkg/props"
-	"os"
+	"image"
 	"shardanalyzer/config"
 	"shardanalyzer/models"
 	"strconv"
 	"time"
-)
-
-func GeneratePDFReport(recommendation models.Recommendation, nodes map[string]*models.NodeStats, isClusterWise bool, fileName string) {
-	begin := time.Now()
-	// m is the PDF report 
-	m := preparePDFReport(recommendation, nodes, isClusterWise)
-
-	err := m.OutputFileAndClose(fileName)																								
-	if err != nil {
-		fmt.Println("File not saved to tmp", err)
-		os.Exit(1)
-	}
-
-	end := time.Now()
-	fmt.Println("Total time took to generate report ", end.Sub(begin))
-	/*
-	open, err := os.Open(fileName)																										
-	fmt.Println("Report available at ", open.Name())
-	defer func(open *os.File) {
-		err := open.Close()
-		if err != nil {
-			fmt.Println("error in closing file", err)
-		}
-	}(open)
-	*/
-}
 
-func GeneratePDFResponse(recommendation models.Recommendation, nodes map[string]*models.NodeStats) (buf bytes.Buffer, err error) {
-	begin := time.Now()
-	m := preparePDFReport(recommendation, nodes, false)
+	"golang.org/x/text/language"
+)
 
-	buf, err = m.Output()
-	if err != nil {
-		fmt.Println("Could not save PDF:", err)
-		return
+func preparePDFReport(recommendation models.Recommendation, nodes map[string]*models.NodeStats, isClusterWise bool, theme Theme, locale language.Tag, generated time.Time) (pdf.Maroto, error) {
+	p := newPrinter(locale)
+	r := newReport(recommendation, nodes, locale, generated)
+	c := theme.getColors()
+	title := r.Title
+	if theme.Title != "" {
+		title = theme.getTitle(recommendation.ClusterName)
 	}
 
-	end := time.Now()
-	fmt.Println(end.Sub(begin))
-	return
-}
-
-func preparePDFReport(recommendation models.Recommendation, nodes map[string]*models.NodeStats, isClusterWise bool) pdf.Maroto {
-	header := getHeader()
-
-	m := pdf.NewMaroto(consts.Portrait, consts.A4)
-	m.SetPageMargins(10, 15, 10)
+	m := pdf.NewMaroto(consts.Portrait, theme.getPageSize())
+	m.SetPageMargins(pageMargin, 15, pageMargin)
 	m.SetAliasNbPages("{nb}")
 	m.SetFirstPageNb(1)
-	addHeaderFooter(m, blue)
+	addHeaderFooter(m, c.primary, theme.Footer, r.BuildStr)
 
+	if theme.Logo != "" {
+		logo, extension, err := theme.getLogo()
+		if err != nil {
+			return nil, err
+		}
+		m.Row(15, func() {
+			m.Col(3, func() {
+				m.Base64Image(logo, extension, props.Rect{Percent: 100})
+			})
+			m.ColSpace(9)
+		})
+	}
 	m.Row(7, func() {
 		m.Col(12, func() {
-			m.Text("Cluster report for "+recommendation.ClusterName, props.Text{
+			m.Text(title, props.Text{
 				Top:    3,
 				Family: consts.Helvetica,
 				Style:  consts.Bold,
@@ -76,7 +55,7 @@ func preparePDFReport(recommendation models.Recommendation, nodes map[string]*mo
 
 	m.Row(2, func() {
 		m.Col(12, func() {
-			m.Text(time.Now().Format("Mon Jan 2, 2006"), props.Text{
+			m.Text(r.Generated, props.Text{
 				Top:    1.5,
 				Size:   6,
 				Family: consts.Helvetica,
@@ -87,91 +66,164 @@ func preparePDFReport(recommendation models.Recommendation, nodes map[string]*mo
 	})
 	m.Row(5, func() {})
 
-	addHeader("Cluster Details", m)
+	attributes := []string{p.Sprintf("column.attribute"), p.Sprintf("column.value")}
+	addSummary(r.Summary, m, c, p)
+	addHeader(p.Sprintf("heading.cluster"), m, c)
+	m.TableList(attributes, r.Cluster, getTwoColumnLeftAlignedTableList(c.stripe))
+	if err := addOverviewCharts(r.indexRows, m, c, p); err != nil {
+		return nil, err
+	}
+	if len(r.Findings.Rows) > 0 {
+		addHeader(p.Sprintf("heading.findings"), m, c)
+		m.TableList(r.Findings.Header, r.Findings.Rows, getFindingTableList(c.stripe))
+	}
 
-	m.TableList([]string{"Attribute", "Value"}, getClusterAttributes(recommendation), getTwoColumnLeftAlignedTableList(sanFranciscoFog))
+	for _, pattern := range r.Patterns {
+		addHeader(p.Sprintf("heading.pattern", pattern.Pattern), m, c)
+		if isClusterWise && len(pattern.Attributes) > 0 {
+			m.TableList(attributes, pattern.Attributes, getTwoColumnLeftAlignedTableList(c.stripe))
+			m.Row(4, func() {})
+		}
+		m.TableList(pattern.Indices.Header, pattern.Indices.Rows, getIndexTableList(c.stripe))
+	}
+	for _, s := range r.Sections {
+		addHeader(s.Title, m, c)
+		m.TableList(s.Table.Header, s.Table.Rows, getIndexTableList(c.stripe))
+	}
 
-	if !isClusterWise {
-		addHeader("Shard Recommendations for indices", m)
-		m.Row(3, func() {})
-		m.TableList(header, getContents(recommendation), getIndexTableList(sanFranciscoFog))
-	} else {
-		addLargerIndices(recommendation, m)
-		for _, ipr := range recommendation.IndexPatternRecommendationRollup {
-			if ipr.NeedChanges {
-				var data [][]string
-				data = getRowContent(ipr)
-				if len(data) > 0 {
-					addHeader("Recommendation for Index pattern "+ipr.Pattern, m)
-					if !ipr.IsIndependentIndexPattern() {
-						m.TableList([]string{"Attribute", "Value"}, getPatternAttributes(ipr), getTwoColumnLeftAlignedTableList(sanFranciscoFog))
-					}
-					m.Row(4, func() {})
-					m.TableList(header, data, getIndexTableList(sanFranciscoFog))
-				}
-			}
+	addHeader(p.Sprintf("heading.nodes"), m, c)
+	if len(nodes) > 0 {
+		if err := addChart(drawNodeCharts(nodes, c.getChartPalette(), p), m); err != nil {
+			return nil, err
 		}
 	}
-	//add cluster skew analysis
-	addClusterSkewAnalysis(nodes, m)
-	return m
+	m.TableList(r.Nodes.Header, r.Nodes.Rows, getClusterTableList(c.stripe))
+
+	addAppendix(r.Appendix, m, c, p)
+	return m, nil
+}
+
+func addSummary(summary []string, m pdf.Maroto, c pdfColors, p printer) {
+	addHeader(p.Sprintf("heading.summary"), m, c)
+	for _, sentence := range summary {
+		m.Row(5, func() {
+			m.Col(12, func() {
+				m.Text(sentence, props.Text{
+					Size:   9,
+					Family: consts.Helvetica,
+					Align:  consts.Left,
+				})
+			})
+		})
+	}
+	m.Row(3, func() {})
+}
+
+// addAppendix lists the commands of the report on their own pages so they can be copied as a whole
+func addAppendix(appendix []commandGroup, m pdf.Maroto, c pdfColors, p printer) {
+	if len(appendix) == 0 {
+		return
+	}
+	m.AddPage()
+	addHeader(p.Sprintf("heading.appendix"), m, c)
+	for _, group := range appendix {
+		var commands [][]string
+		for _, cmd := range group.Commands {
+			commands = append(commands, []string{cmd})
+		}
+		m.TableList([]string{group.Title}, commands, getCommandTableList(c.stripe))
+		m.Row(3, func() {})
+	}
 }
 
-func getBuildStr() string {
+func getBuildStr(p printer) string {
 	if config.Version == "" {
 		config.Version = "1.0.0"
 		config.Build = "Development"
 	}
-	by := fmt.Sprintf("Generated with ShardAnalyzer version: %s , build: %s", config.Version, config.Build)
+	by := p.Sprintf("report.build", config.Version, config.Build)
 	return by
 }
 
-func addClusterSkewAnalysis(nodes map[string]*models.NodeStats, m pdf.Maroto) {
-	addHeader("Node distribution", m)
-	m.TableList([]string{"Node", "Total shards (P/R)", "Size"}, getNodeDetails(nodes), getClusterTableList(sanFranciscoFog))
-}
-
-func getNodeDetails(nodes map[string]*models.NodeStats) (data [][]string) {
-	for _, ns := range nodes {
+func getNodeDetails(nodes map[string]*models.NodeStats, p printer) (t table) {
+	t.Header = []string{p.Sprintf("column.node"), p.Sprintf("column.nodeShards"), p.Sprintf("column.size")}
+	for _, name := range getNodeNamesBySize(nodes) {
+		ns := nodes[name]
 		total := strconv.Itoa(ns.PrimaryShardsCount+ns.ReplicaShardsCount) + " (" + strconv.Itoa(ns.PrimaryShardsCount) + "/" + strconv.Itoa(ns.ReplicaShardsCount) + ")"
-		size := ByteCountIEC(ns.PrimarySizeBytes+ns.ReplicaSizeBytes) + " (" + ByteCountIEC(ns.PrimarySizeBytes) + "/" + ByteCountIEC(ns.ReplicaSizeBytes) + ")"
-		data = append(data, []string{ns.NodeName, total, size})
+		size := p.bytes(ns.PrimarySizeBytes+ns.ReplicaSizeBytes) + " (" + p.bytes(ns.PrimarySizeBytes) + "/" + p.bytes(ns.ReplicaSizeBytes) + ")"
+		t.addRow([]string{ns.NodeName, total, size}, "", fmt.Sprint(ns.PrimaryShardsCount+ns.ReplicaShardsCount), fmt.Sprint(getNodeBytes(ns)))
 	}
 	return
 }
 
-func addLargerIndices(recommendation models.Recommendation, m pdf.Maroto) {
-	available, indices := recommendation.GetIndicesWithLargerShards(50)
-	if available {
-		var data [][]string
-		for _, ir := range indices {
-			rowData := []string{ir.Name, ByteCountIEC(ir.PrimarySizeInBytes), strconv.Itoa(ir.Primaries) + "/" + strconv.Itoa(ir.Replicas/ir.Primaries), strconv.Itoa(ir.PotentialPrimaries) + "/" + strconv.Itoa(ir.PotentialReplicas)}
-			data = append(data, rowData)
-		}
-		addHeader("Indices have shards greater than 50GB", m)
-		m.TableList(getHeader(), data, getIndexTableList(sanFranciscoFog))
+func addOverviewCharts(rows []IndexRow, m pdf.Maroto, c pdfColors, p printer) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	addHeader(p.Sprintf("heading.charts"), m, c)
+	if err := addChart(drawShardSizeHistogram(rows, c.getChartPalette(), p), m); err != nil {
+		return err
+	}
+	m.Row(3, func() {})
+	return addChart(drawStorageTreemap(rows, c.getChartPalette(), p), m)
+}
+
+// left and right page margin in mm
+const pageMargin = 10.0
+
+// addChart embeds the chart as a PNG across the page, the row takes the height of the image
+func addChart(img image.Image, m pdf.Maroto) error {
+	encoded, err := encodePNG(img)
+	if err != nil {
+		return fmt.Errorf("drawing chart: %w", err)
+	}
+	bounds := img.Bounds()
+	pageWidth, _ := m.GetPageSize()
+	m.Row((pageWidth-2*pageMargin)*float64(bounds.Dy())/float64(bounds.Dx()), func() {
+		m.Col(12, func() {
+			m.Base64Image(encoded, consts.Png, props.Rect{Percent: 100, Center: true})
+		})
+	})
+	return nil
+}
+
+func getFindingHeader(p printer) []string {
+	return []string{p.Sprintf("column.severity"), p.Sprintf("column.findingIndex"), p.Sprintf("column.finding"), p.Sprintf("column.remediation")}
+}
+
+// getFindingRows translates the severities, the messages of the analysis are English
+func getFindingRows(findings []models.Finding, p printer) (data [][]string) {
+	for _, f := range findings {
+		data = append(data, []string{p.Sprintf("severity." + string(f.Severity)), f.Index, f.Message, f.Remediation})
 	}
+	return
 }
 
-func addHeader(header string, m pdf.Maroto) {
+func addHeader(header string, m pdf.Maroto, c pdfColors) {
 	m.SetBackgroundColor(white)
-	m.TableList([]string{""}, [][]string{{header}}, getBoxTableList(pacificSky))
+	m.TableList([]string{""}, [][]string{{header}}, getBoxTableList(c.accent))
 	m.Row(3, func() {})
 }
 
-func getPatternAttributes(ipr models.IndexPatternRecommendation) (data [][]string) {
+func getPatternAttributes(ipr models.IndexPatternRecommendation, p printer) (data [][]string) {
 	data = [][]string{
-		{"Pattern Name", ipr.Pattern},
-		{"No of indices found in this pattern", strconv.Itoa(ipr.GetCount())},
-		{"Primary Shards", strconv.Itoa(ipr.PrimaryShards)},
-		{"Replica Shards", strconv.Itoa(ipr.ReplicaShards)},
-		{"Size of Primary Indices", ByteCountIEC(ipr.Size)},
-		{"Potential Primary Shards", strconv.Itoa(ipr.PotentialPrimaryShards)},
-		{"Potential Replica Shards", strconv.Itoa(ipr.PotentialReplicaShards)},
-		//
-		{"Recommended Index template", ipr.GetIndexTemplateCommand()},
+		{p.Sprintf("pattern.name"), ipr.Pattern},
+		{p.Sprintf("pattern.indices"), p.number(int64(ipr.GetCount()))},
+		{p.Sprintf("pattern.primaryShards"), p.number(int64(ipr.PrimaryShards))},
+		{p.Sprintf("pattern.replicaShards"), p.number(int64(ipr.ReplicaShards))},
+		{p.Sprintf("pattern.primarySize"), p.bytes(ipr.Size)},
+		{p.Sprintf("pattern.potentialPrimaryShards"), p.number(int64(ipr.PotentialPrimaryShards))},
+		{p.Sprintf("pattern.potentialReplicaShards"), p.number(int64(ipr.PotentialReplicaShards))},
+	}
+	if ipr.IsDataStream() {
+		data = append(data, []string{p.Sprintf("pattern.dataStream"), ipr.DataStream})
+	}
+	if ipr.Alias != "" {
+		data = append(data, []string{p.Sprintf("pattern.alias"), ipr.Alias})
+	}
+	if ipr.WriteIndex != "" {
+		data = append(data, []string{p.Sprintf("pattern.writeIndex"), ipr.WriteIndex})
 	}
-	fmt.Println(ipr.GetIndexTemplateCommand())
 	return
 }
 
@@ -209,6 +261,44 @@ func getBoxTableList(grayColor color.Color) props.TableList {
 	}
 }
 
+func getFindingTableList(color color.Color) props.TableList {
+	return props.TableList{
+		HeaderProp: props.TableListContent{
+			Size:      9,
+			GridSizes: []uint{1, 3, 4, 4},
+			Family:    consts.Helvetica,
+		},
+		ContentProp: props.TableListContent{
+			Size:      7,
+			GridSizes: []uint{1, 3, 4, 4},
+			Family:    consts.Helvetica,
+		},
+		Align:                consts.Left,
+		AlternatedBackground: &color,
+		HeaderContentSpace:   1,
+		Line:                 false,
+	}
+}
+
+func getCommandTableList(color color.Color) props.TableList {
+	return props.TableList{
+		HeaderProp: props.TableListContent{
+			Size:      9,
+			GridSizes: []uint{12},
+			Family:    consts.Courier,
+		},
+		ContentProp: props.TableListContent{
+			Size:      7,
+			GridSizes: []uint{12},
+			Family:    consts.Courier,
+		},
+		Align:                consts.Left,
+		AlternatedBackground: &color,
+		HeaderContentSpace:   1,
+		Line:                 false,
+	}
+}
+
 func getIndexTableList(color color.Color) props.TableList {
 	return props.TableList{
 		HeaderProp: props.TableListContent{
@@ -232,12 +322,12 @@ func getClusterTableList(color color.Color) props.TableList {
 	return props.TableList{
 		HeaderProp: props.TableListContent{
 			Size:      9,
-			GridSizes: []uint{3, 3, 2, 2, 2},
+			GridSizes: []uint{4, 4, 4},
 			Family:    consts.Helvetica,
 		},
 		ContentProp: props.TableListContent{
 			Size:      8,
-			GridSizes: []uint{3, 3, 2, 2, 2},
+			GridSizes: []uint{4, 4, 4},
 			Family:    consts.Helvetica,
 		},
 		Align:                consts.Center,
@@ -247,11 +337,11 @@ func getClusterTableList(color color.Color) props.TableList {
 	}
 }
 
-func addHeaderFooter(m pdf.Maroto, color color.Color) {
+func addHeaderFooter(m pdf.Maroto, color color.Color, footer string, buildStr string) {
 	m.RegisterHeader(func() {
 		m.Row(3, func() {
 			m.Col(12, func() {
-				m.Text(getBuildStr(), props.Text{
+				m.Text(buildStr, props.Text{
 					Style:  consts.Normal,
 					Size:   6,
 					Family: consts.Helvetica,
@@ -263,7 +353,7 @@ func addHeaderFooter(m pdf.Maroto, color color.Color) {
 	m.RegisterFooter(func() {
 		m.Row(6, func() {
 			m.Col(5, func() {
-				m.Text("aws.amazon.com/opensearch-service", props.Text{
+				m.Text(footer, props.Text{
 					Style: consts.BoldItalic,
 					Size:  8,
 					Align: consts.Left,
@@ -281,50 +371,49 @@ func addHeaderFooter(m pdf.Maroto, color color.Color) {
 	})
 }
 
-func getClusterAttributes(recommendation models.Recommendation) (data [][]string) {
-	data = [][]string{
-		{"Cluster Name", recommendation.Title},
-		{"Number of Data nodes", strconv.Itoa(recommendation.NumberOfDataNodes)},
-		{"Number of AZs", strconv.Itoa(recommendation.NumberOfAZs)},
-		{"Total Indices", strconv.Itoa(recommendation.GetIndexCount())},					// Index Count is from summing length of each Indices array within the IndexPatternRecommendation that is within the IndexPatternRecommendationRollup array
-		{"Total index patterns", strconv.Itoa(recommendation.GetTotalIndexPatterns())},		// number of IndexPatternRecommendation structs that have Pattern!=No Patterns
-		{"Size of Primary Indices", ByteCountIEC(recommendation.TotalPrimarySize)},
-		{"Size of Replica Indices", ByteCountIEC(recommendation.TotalReplicaSize)},
-		{"Target Shard Size in GB", strconv.Itoa(recommendation.RecommendedShardSizeInGb)},
-		{"Total Shards", strconv.Itoa(recommendation.TotalShards)},
-		{"Potential Shards", strconv.Itoa(recommendation.PotentialShards)},
-		
-		// is missing recommendation.ClusterName
+func getClusterAttributes(recommendation models.Recommendation, p printer) (data [][]string) {
+	data = [][]string{{p.Sprintf("cluster.name"), recommendation.ClusterName}}
+	if recommendation.Title != "" {
+		data = append(data, []string{p.Sprintf("cluster.client"), recommendation.Title})
+	}
+	data = append(data, [][]string{
+		{p.Sprintf("cluster.dataNodes"), p.number(int64(recommendation.NumberOfDataNodes))},
+		{p.Sprintf("cluster.azs"), p.number(int64(recommendation.NumberOfAZs))},
+		{p.Sprintf("cluster.indices"), p.number(int64(len(GetIndexRows(recommendation))))},				// GetIndexCount counts the indices merged into single index patterns twice
+		{p.Sprintf("cluster.patterns"), p.number(int64(recommendation.GetTotalIndexPatterns()))},		// number of IndexPatternRecommendation structs that have Pattern!=No Patterns
+		{p.Sprintf("cluster.primarySize"), p.bytes(recommendation.TotalPrimarySize)},
+		{p.Sprintf("cluster.replicaSize"), p.bytes(recommendation.TotalReplicaSize)},
+		{p.Sprintf("cluster.targetSize"), p.number(int64(recommendation.RecommendedShardSizeInGb))},
+		{p.Sprintf("cluster.targetDocs"), p.number(recommendation.TargetDocsPerShard)},
+		{p.Sprintf("cluster.shards"), p.number(int64(recommendation.TotalShards))},
+		{p.Sprintf("cluster.potentialShards"), p.number(int64(recommendation.PotentialShards))},
+		{p.Sprintf("cluster.systemIndices"), p.number(int64(recommendation.SystemIndexCount))},
+		{p.Sprintf("cluster.hiddenIndices"), p.number(int64(recommendation.HiddenIndexCount))},
+		{p.Sprintf("cluster.dataStreamIndices"), p.number(int64(recommendation.DataStreamIndexCount))},
+	}...)
+	if recommendation.CleanupShards > 0 {
+		data = append(data, []string{
+			p.Sprintf("cluster.cleanupShards"), p.number(int64(recommendation.CleanupShards)),
+		})
 	}
 	if len(recommendation.EmptyIndices) > 0 {
 		data = append(data, []string{
-			"Empty Indices", fmt.Sprint(recommendation.EmptyIndices),
+			p.Sprintf("cluster.emptyIndices"), fmt.Sprint(recommendation.EmptyIndices),
 		})
 	}
 	return
 }
 
-func getHeader() []string {
-	return []string{"Index Name", "Primary Size", "Current Settings p/r", "Recommended Settings p/r"}
-}
-
-func getContents(recommendation models.Recommendation) (data [][]string) {
-	data = [][]string{}
-	for _, ipr := range recommendation.IndexPatternRecommendationRollup {
-		if ipr.NeedChanges {
-			data = getRowContent(ipr)
-		}
-
-	}
-	return
+func getHeader(p printer) []string {
+	return []string{p.Sprintf("column.index"), p.Sprintf("column.primarySize"), p.Sprintf("column.current"), p.Sprintf("column.recommended")}
 }
 
-func getRowContent(ipr models.IndexPatternRecommendation) (data [][]string) {
-	if ipr.IsIndependentIndexPattern() || len(ipr.Indices) > 1 {
+func getRowContent(ipr models.IndexPatternRecommendation, p printer) (t table) {
+	t.Header = getHeader(p)
+	if ipr.IsIndependentIndexPattern() || ipr.IsDataStream() || len(ipr.Indices) > 1 {
 		for _, ir := range ipr.Indices {
-			rowData := []string{ir.Name, ByteCountIEC(ir.PrimarySizeInBytes), strconv.Itoa(ir.Primaries) + "/" + strconv.Itoa(ir.Replicas/ir.Primaries), strconv.Itoa(ir.PotentialPrimaries) + "/" + strconv.Itoa(ir.PotentialReplicas)}
-			data = append(data, rowData)
+			t.addIndexRow(ir, p)
 		}
 	}
-	return data
+	return t
 }
@@ -0,0 +1,93 @@
+package reports
+
+import (
+	"io"
+	"shardanalyzer/models"
+	"strings"
+	"time"
+
+	"golang.org/x/text/language"
+)
+
+// Renderer writes the report of a recommendation in one format.
+type Renderer interface {
+	ContentType() string
+	Render(w io.Writer, recommendation models.Recommendation, nodes map[string]*models.NodeStats) error
+}
+
+// Options customise the reports of a request.
+type Options struct {
+	Theme  Theme            // of the PDF report
+	Locale language.Tag     // of the PDF, text, Markdown and HTML reports, CSV and XLSX stay English
+	Now    func() time.Time // clock of the generated date of the reports, time.Now when nil
+}
+
+// GetRenderer returns the renderer of a negotiated content type, JSON has none as the transports
+// marshal their own response.
+func GetRenderer(contentType string, options Options) (Renderer, bool) {
+	switch contentType {
+	case ContentTypePDF:
+		return PDFRenderer{Theme: options.Theme, Locale: options.Locale, Now: options.Now}, true
+	case ContentTypeText:
+		return TableRenderer{Locale: options.Locale}, true
+	case ContentTypeMarkdown:
+		return MarkdownRenderer{Locale: options.Locale, Now: options.Now}, true
+	case ContentTypeHTML:
+		return HTMLRenderer{Locale: options.Locale, Now: options.Now}, true
+	case ContentTypeCSV:
+		return CSVRenderer{}, true
+	case ContentTypeXLSX:
+		return XLSXRenderer{}, true
+	}
+	return nil, false
+}
+
+// IsBinary tells if the output of the content type has to be base64 encoded in Lambda responses.
+func IsBinary(contentType string) bool {
+	return !strings.HasPrefix(contentType, "text/") && contentType != ContentTypeJSON
+}
+
+type PDFRenderer struct {
+	IsClusterWise bool             // the attributes of every index pattern, not only its indices
+	Theme         Theme            // merged over DefaultTheme, unset fields keep the defaults
+	Locale        language.Tag     // English when unset
+	Now           func() time.Time // time.Now when nil
+}
+
+func (PDFRenderer) ContentType() string {
+	return ContentTypePDF
+}
+
+func (p PDFRenderer) Render(w io.Writer, recommendation models.Recommendation, nodes map[string]*models.NodeStats) error {
+	m, err := preparePDFReport(recommendation, nodes, p.IsClusterWise, DefaultTheme().Merge(p.Theme), p.Locale, getNow(p.Now))
+	if err != nil {
+		return err
+	}
+	buf, err := m.Output()
+	if err != nil {
+		return err
+	}
+	_, err = buf.WriteTo(w)
+	return err
+}
+
+type TableRenderer struct {
+	Locale language.Tag // English when unset
+}
+
+func (TableRenderer) ContentType() string {
+	return ContentTypeText
+}
+
+func (t TableRenderer) Render(w io.Writer, recommendation models.Recommendation, nodes map[string]*models.NodeStats) error {
+	_, err := io.WriteString(w, renderAsTable(recommendation, newPrinter(t.Locale)))
+	return err
+}
+
+// getNow reads the clock of a renderer
+func getNow(now func() time.Time) time.Time {
+	if now == nil {
+		return time.Now()
+	}
+	return now()
+}
@@ -0,0 +1,74 @@
+package reports
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/language"
+)
+
+func Test_markdownRenderer(t *testing.T) {
+	recommendation, nodes := getTestCluster(t, catShards, "<prod|logs>")
+	var buf bytes.Buffer
+	assert.NoError(t, MarkdownRenderer{}.Render(&buf, recommendation, nodes))
+	markdown := buf.String()
+
+	assert.Contains(t, markdown, `# Cluster report for <prod\|logs>`)
+	assert.Contains(t, markdown, "| Attribute | Value |\n| --- | --- |\n")
+	assert.Contains(t, markdown, `### logs-\*\*\*\*.\*\*.\*\*`)
+	assert.Contains(t, markdown, "| logs-2023.01.01 | 2.0 GB | 2/1 | 1/1 |")
+	assert.Contains(t, markdown, "## Executive summary\n\n- The cluster has 2 indices")
+	assert.Contains(t, markdown, "### Index templates\n\n```\nPOST _template/")
+	// nodes of the same size are sorted by name so the report is stable
+	assert.Less(t, strings.Index(markdown, "| node-1 |"), strings.Index(markdown, "| node-2 |"))
+	// no cell breaks its row
+	for _, line := range strings.Split(markdown, "\n") {
+		if strings.HasPrefix(line, "|") {
+			assert.True(t, strings.HasSuffix(line, "|"), line)
+		}
+	}
+}
+
+func Test_htmlRenderer(t *testing.T) {
+	recommendation, nodes := getTestCluster(t, catShards, "<prod|logs>")
+	var buf bytes.Buffer
+	assert.NoError(t, HTMLRenderer{}.Render(&buf, recommendation, nodes))
+	html := buf.String()
+
+	assert.True(t, strings.HasPrefix(html, "<!DOCTYPE html>"))
+	assert.Contains(t, html, "<title>Cluster report for &lt;prod|logs&gt;</title>")
+	assert.Contains(t, html, "<summary>logs-****.**.** (2 indices)</summary>")
+	assert.Contains(t, html, `<table class="sortable">`)
+	assert.Contains(t, html, "<td>logs-2023.01.01</td>")
+	assert.Contains(t, html, `<td>node-1</td><td data-value="4">4 (2/2)</td><td data-value="4294967296">4.0 GB (2.0 GB/2.0 GB)</td>`)
+	assert.Contains(t, html, "<summary>Index templates (1)</summary>")
+	// assets are inlined so the file works on its own
+	assert.Contains(t, html, "<style>body {")
+	assert.Contains(t, html, `document.querySelectorAll("table.sortable")`)
+	assert.NotContains(t, html, "ZgotmplZ")
+	assert.NotContains(t, html, "<link")
+
+	buf.Reset()
+	assert.NoError(t, HTMLRenderer{Locale: language.German}.Render(&buf, recommendation, nodes))
+	html = buf.String()
+	assert.Contains(t, html, `<html lang="de">`)
+	assert.Contains(t, html, "<h2>Zusammenfassung</h2>")
+	// the tables sort by the raw numbers, not by the localized text
+	assert.Contains(t, html, `<td data-value="2147483648">2,0 GB</td>`)
+	// the classes of the stylesheet stay English
+	assert.NotContains(t, html, "severity-Warnung")
+}
+
+func Test_getRenderer(t *testing.T) {
+	for _, contentType := range []string{ContentTypePDF, ContentTypeText, ContentTypeMarkdown, ContentTypeHTML} {
+		renderer, ok := GetRenderer(contentType, Options{})
+		assert.True(t, ok, contentType)
+		assert.Equal(t, contentType, renderer.ContentType())
+	}
+	_, ok := GetRenderer(ContentTypeJSON, Options{})
+	assert.False(t, ok)
+	assert.True(t, IsBinary(ContentTypePDF))
+	assert.False(t, IsBinary(ContentTypeHTML))
+}
@@ -0,0 +1,259 @@
+package reports
+
+import (
+	"fmt"
+	"shardanalyzer/models"
+	"sort"
+	"strconv"
+	"time"
+
+	"golang.org/x/text/language"
+)
+
+// report is the content of the PDF, Markdown and HTML reports in the order they lay it out, the
+// renderers only add layout
+type report struct {
+	Lang              string
+	Title             string
+	Generated         string
+	BuildStr          string
+	Summary           []string
+	Cluster           [][]string
+	Findings          table
+	FindingSeverities []string // of the rows of Findings, untranslated
+	Patterns          []patternView
+	Sections          []section
+	Nodes             table
+	Appendix          []commandGroup
+	indexRows         []IndexRow // for the charts
+}
+
+type table struct {
+	Header []string
+	Rows   [][]string
+	Values [][]string // raw numbers of the cells the HTML report sorts by, the text sorts cells without one
+}
+
+// addRow adds the cells of a row with the raw numbers of its numeric cells, "" for the others
+func (t *table) addRow(cells []string, values ...string) {
+	t.Rows = append(t.Rows, cells)
+	t.Values = append(t.Values, values)
+}
+
+// Value is the raw number of the cell, "" when it sorts by its text
+func (t table) Value(row, column int) string {
+	if row < len(t.Values) && column < len(t.Values[row]) {
+		return t.Values[row][column]
+	}
+	return ""
+}
+
+// addIndexRow adds the current and the recommended shards of the index to a table of getHeader
+func (t *table) addIndexRow(ir *models.IndexRecommendation, p printer) {
+	t.addRow([]string{ir.Name, p.bytes(ir.PrimarySizeInBytes), strconv.Itoa(ir.Primaries) + "/" + strconv.Itoa(ir.Replicas/ir.Primaries), strconv.Itoa(ir.PotentialPrimaries) + "/" + strconv.Itoa(ir.PotentialReplicas)},
+		"", fmt.Sprint(ir.PrimarySizeInBytes), fmt.Sprint(ir.Primaries), fmt.Sprint(ir.PotentialPrimaries))
+}
+
+type patternView struct {
+	Pattern    string
+	Summary    string     // the pattern with its number of indices
+	Attributes [][]string // empty for the patterns of single indices
+	Indices    table
+}
+
+type section struct {
+	Title string
+	Table table
+}
+
+type commandGroup struct {
+	Title    string
+	Commands []string
+}
+
+func newReport(recommendation models.Recommendation, nodes map[string]*models.NodeStats, locale language.Tag, generated time.Time) report {
+	p := newPrinter(locale)
+	rows := GetIndexRows(recommendation)
+	r := report{
+		Lang:      p.tag.String(),
+		Title:     p.Sprintf("report.title", recommendation.ClusterName),
+		Generated: p.date(generated),
+		BuildStr:  getBuildStr(p),
+		Summary:   getExecutiveSummary(recommendation, rows, nodes, p),
+		Cluster:   getClusterAttributes(recommendation, p),
+		Findings:  table{Header: getFindingHeader(p), Rows: getFindingRows(recommendation.Findings, p)},
+		Nodes:     getNodeDetails(nodes, p),
+		indexRows: rows,
+	}
+	for _, f := range recommendation.Findings {
+		r.FindingSeverities = append(r.FindingSeverities, string(f.Severity))
+	}
+	var templates []string
+	for _, ipr := range recommendation.IndexPatternRecommendationRollup {
+		if !ipr.NeedChanges {
+			continue
+		}
+		indices := getRowContent(ipr, p)
+		if len(indices.Rows) == 0 {
+			continue
+		}
+		pattern := patternView{Pattern: ipr.Pattern, Summary: p.Sprintf("pattern.summary", ipr.Pattern, len(indices.Rows)), Indices: indices}
+		if !ipr.IsIndependentIndexPattern() {
+			pattern.Attributes = getPatternAttributes(ipr, p)
+			templates = append(templates, ipr.GetIndexTemplateCommand())
+		}
+		r.Patterns = append(r.Patterns, pattern)
+	}
+	r.Sections = getSections(recommendation, p)
+	r.Appendix = getAppendix(recommendation, templates, p)
+	return r
+}
+
+// getExecutiveSummary returns the sentences that open the report
+func getExecutiveSummary(recommendation models.Recommendation, rows []IndexRow, nodes map[string]*models.NodeStats, p printer) (summary []string) {
+	summary = append(summary, p.Sprintf("summary.cluster",
+		len(rows), recommendation.GetTotalIndexPatterns(), recommendation.NumberOfDataNodes, p.bytes(recommendation.TotalPrimarySize), p.bytes(recommendation.TotalReplicaSize)))
+
+	total, potential := recommendation.TotalShards, recommendation.PotentialShards
+	switch {
+	case potential < total:
+		summary = append(summary, p.Sprintf("summary.fewerShards", total, potential, (total-potential)*100/total))
+	case potential > total:
+		summary = append(summary, p.Sprintf("summary.moreShards", total, potential))
+	default:
+		summary = append(summary, p.Sprintf("summary.sameShards", total))
+	}
+	changes := 0
+	for _, row := range rows {
+		if row.NeedsChange {
+			changes++
+		}
+	}
+	if changes > 0 {
+		summary = append(summary, p.Sprintf("summary.changes", changes, len(rows)))
+	}
+
+	severities := make(map[models.Severity]int)
+	for _, f := range recommendation.Findings {
+		severities[f.Severity]++
+	}
+	if len(recommendation.Findings) == 0 {
+		summary = append(summary, p.Sprintf("summary.noFindings"))
+	} else {
+		summary = append(summary, p.Sprintf("summary.findings",
+			severities[models.SeverityCritical], severities[models.SeverityWarning], severities[models.SeverityInfo]))
+	}
+
+	if names := getNodeNamesBySize(nodes); len(names) > 1 {
+		fullest, emptiest := nodes[names[0]], nodes[names[len(names)-1]]
+		summary = append(summary, p.Sprintf("summary.nodes",
+			fullest.NodeName, p.bytes(getNodeBytes(fullest)), emptiest.NodeName, p.bytes(getNodeBytes(emptiest))))
+	}
+	if len(recommendation.CleanupIndices) > 0 {
+		summary = append(summary, p.Sprintf("summary.cleanup", len(recommendation.CleanupIndices), recommendation.CleanupShards))
+	}
+	return
+}
+
+// getSections returns the tables that follow the index patterns, empty ones left out
+func getSections(recommendation models.Recommendation, p printer) (sections []section) {
+	columns := func(keys ...string) (header []string) {
+		for _, key := range keys {
+			header = append(header, p.Sprintf(key))
+		}
+		return
+	}
+	if available, indices := recommendation.GetIndicesWithLargerShards(recommendation.Thresholds.LargeShardGB); available {
+		s := section{Title: p.Sprintf("section.largeShards", recommendation.Thresholds.LargeShardGB), Table: table{Header: getHeader(p)}}
+		for _, ir := range indices {
+			s.Table.addIndexRow(&ir, p)
+		}
+		sections = append(sections, s)
+	}
+	if len(recommendation.CleanupIndices) > 0 {
+		s := section{Title: p.Sprintf("section.cleanup", recommendation.CleanupShards), Table: table{Header: columns("column.index", "column.docs", "column.primarySize", "column.shards")}}
+		for _, ci := range recommendation.CleanupIndices {
+			name := ci.Name
+			if ci.Closed {
+				name = p.Sprintf("index.closed", ci.Name)
+			}
+			s.Table.addRow([]string{name, p.number(ci.Docs), p.bytes(ci.PrimarySizeInBytes), strconv.Itoa(ci.Primaries) + "/" + strconv.Itoa(ci.Replicas)},
+				"", fmt.Sprint(ci.Docs), fmt.Sprint(ci.PrimarySizeInBytes), fmt.Sprint(ci.Primaries))
+		}
+		sections = append(sections, s)
+	}
+	forceMerge := section{Title: p.Sprintf("section.forceMerge"), Table: table{Header: columns("column.index", "column.segments", "column.segmentsPerShard", "column.deletedDocs")}}
+	for _, sr := range recommendation.SegmentRecommendations {
+		if sr.NeedsForceMerge {
+			forceMerge.Table.addRow([]string{sr.Name, p.number(int64(sr.Segments)), p.Sprintf("%.1f", sr.SegmentsPerShard), p.Sprintf("%.1f%%", sr.DeletedDocsRatio*100)},
+				"", fmt.Sprint(sr.Segments), fmt.Sprint(sr.SegmentsPerShard), fmt.Sprint(sr.DeletedDocsRatio))
+		}
+	}
+	if len(forceMerge.Table.Rows) > 0 {
+		sections = append(sections, forceMerge)
+	}
+	if len(recommendation.BloatedIndices) > 0 {
+		s := section{Title: p.Sprintf("section.bloated"), Table: table{Header: columns("column.index", "column.deletedDocs", "column.sizePerDoc", "column.reclaimable")}}
+		for _, ib := range recommendation.BloatedIndices {
+			s.Table.addRow([]string{ib.Name, p.Sprintf("%.1f%%", ib.DeletedDocsRatio*100), p.bytes(int64(ib.BytesPerDoc)), p.bytes(ib.ReclaimableBytes)},
+				"", fmt.Sprint(ib.DeletedDocsRatio), fmt.Sprint(ib.BytesPerDoc), fmt.Sprint(ib.ReclaimableBytes))
+		}
+		sections = append(sections, s)
+	}
+	if len(recommendation.SkewedIndices) > 0 {
+		s := section{Title: p.Sprintf("section.skewed"), Table: table{Header: columns("column.index", "column.largestShard", "column.medianShard", "column.smallestShard")}}
+		for _, skew := range recommendation.SkewedIndices {
+			s.Table.addRow([]string{skew.Name, p.bytes(skew.MaxShardSizeInBytes), p.bytes(skew.MedianShardSizeInBytes), p.bytes(skew.MinShardSizeInBytes)},
+				"", fmt.Sprint(skew.MaxShardSizeInBytes), fmt.Sprint(skew.MedianShardSizeInBytes), fmt.Sprint(skew.MinShardSizeInBytes))
+		}
+		sections = append(sections, s)
+	}
+	if len(recommendation.ReportedIndices) > 0 {
+		s := section{Title: p.Sprintf("section.reported"), Table: table{Header: columns("column.index", "column.class", "column.primarySize", "column.shards")}}
+		for _, ci := range recommendation.ReportedIndices {
+			s.Table.addRow([]string{ci.Name, string(ci.Class), p.bytes(ci.PrimarySizeInBytes), strconv.Itoa(ci.Primaries) + "/" + strconv.Itoa(ci.Replicas)},
+				"", "", fmt.Sprint(ci.PrimarySizeInBytes), fmt.Sprint(ci.Primaries))
+		}
+		sections = append(sections, s)
+	}
+	return
+}
+
+// getAppendix returns the commands the report recommends, grouped by what they do
+func getAppendix(recommendation models.Recommendation, templates []string, p printer) (appendix []commandGroup) {
+	var remediations []string
+	for _, ib := range recommendation.BloatedIndices {
+		remediations = append(remediations, ib.Command)
+	}
+	groups := []commandGroup{
+		{p.Sprintf("appendix.templates"), templates},
+		{p.Sprintf("appendix.cleanup"), recommendation.GetCleanupCommands(models.DefaultCleanupBatchSize)},
+		{p.Sprintf("appendix.forceMerge"), recommendation.GetForceMergeCommands()},
+		{p.Sprintf("appendix.remediation"), remediations},
+	}
+	for _, group := range groups {
+		if len(group.Commands) > 0 {
+			appendix = append(appendix, group)
+		}
+	}
+	return
+}
+
+func getNodeBytes(ns *models.NodeStats) int64 {
+	return ns.PrimarySizeBytes + ns.ReplicaSizeBytes
+}
+
+// getNodeNamesBySize returns the nodes with the most bytes first, nodes of the same size by name
+func getNodeNamesBySize(nodes map[string]*models.NodeStats) []string {
+	names := make([]string, 0, len(nodes))
+	for name := range nodes {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if a, b := getNodeBytes(nodes[names[i]]), getNodeBytes(nodes[names[j]]); a != b {
+			return a > b
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
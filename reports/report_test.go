@@ -0,0 +1,193 @@
+package reports
+
+import (
+	"bytes"
+	"compress/zlib"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"shardanalyzer/config"
+	"shardanalyzer/models"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/language"
+)
+
+var update = flag.Bool("update", false, "update the golden files of the reports")
+
+// a pattern of daily indices with two primaries each
+var catShards = readTestdata("cat_shards.txt")
+
+// a cluster with an index per kind of recommendation: a pattern of daily indices, a single index
+// with large shards, an empty index and a node with less data than the others
+var catShardsMixed = readTestdata("cat_shards_mixed.txt")
+
+// readTestdata reads a fixture of the testdata directory the packages share
+func readTestdata(name string) string {
+	data, err := os.ReadFile(filepath.Join("..", "testdata", name))
+	if err != nil {
+		panic(err)
+	}
+	return string(data)
+}
+
+func getTestReport(t *testing.T, shards string, locale language.Tag) report {
+	recommendation, nodes := getTestCluster(t, shards, "test")
+	return newReport(recommendation, nodes, locale, testNow())
+}
+
+// getTestCluster analyzes the shards of a cluster named and titled name
+func getTestCluster(t *testing.T, shards string, name string) (models.Recommendation, map[string]*models.NodeStats) {
+	args := config.ShardRecommendationRequest{CatShards: shards, TargetShardSizeGB: 30, NumberOfAzs: 2, ClusterName: name, ClientName: name}
+	cluster, err := args.ParseStats()
+	assert.NoError(t, err)
+	recommendation := cluster.PrepareRecommendation()
+	MergeSingleIndexPatterns(&recommendation)
+	return recommendation, cluster.Nodes
+}
+
+// the reports are generated on a fixed date so they stay the same between runs
+func testNow() time.Time {
+	return time.Date(2024, 1, 31, 12, 0, 0, 0, time.UTC)
+}
+
+var (
+	pdfStream = regexp.MustCompile(`<<([^<>]*)>>\s*stream\r?\n`)
+	pdfText   = regexp.MustCompile(`BT ([\d.]+) ([\d.]+) Td \(((?:\\.|[^\\)])*)\) Tj ET`)
+)
+
+// extractPDFText returns the text the pages of the PDF show, a line per baseline with the cells of
+// a table row separated by " | " and the pages separated by a blank line
+func extractPDFText(t *testing.T, pdf []byte) string {
+	var out strings.Builder
+	for _, match := range pdfStream.FindAllSubmatchIndex(pdf, -1) {
+		dict := string(pdf[match[2]:match[3]])
+		if !strings.Contains(dict, "/FlateDecode") || strings.Contains(dict, "/Subtype") {
+			continue // the pages are the only compressed streams without a subtype
+		}
+		reader, err := zlib.NewReader(bytes.NewReader(pdf[match[1]:]))
+		assert.NoError(t, err)
+		content, err := io.ReadAll(reader)
+		assert.NoError(t, err)
+
+		type text struct {
+			x, y float64
+			s    string
+		}
+		var texts []text
+		for _, tj := range pdfText.FindAllSubmatch(content, -1) {
+			x, _ := strconv.ParseFloat(string(tj[1]), 64)
+			y, _ := strconv.ParseFloat(string(tj[2]), 64)
+			if s := unescapePDFString(t, tj[3]); strings.TrimSpace(s) != "" {
+				texts = append(texts, text{x, y, s})
+			}
+		}
+		// top to bottom, left to right
+		sort.SliceStable(texts, func(i, j int) bool {
+			if texts[i].y != texts[j].y {
+				return texts[i].y > texts[j].y
+			}
+			return texts[i].x < texts[j].x
+		})
+		for i, text := range texts {
+			switch {
+			case i == 0:
+			case text.y == texts[i-1].y:
+				out.WriteString(" | ")
+			default:
+				out.WriteString("\n")
+			}
+			out.WriteString(text.s)
+		}
+		out.WriteString("\n\n")
+	}
+	return out.String()
+}
+
+// unescapePDFString decodes a literal string of the core fonts, which are Windows-1252
+func unescapePDFString(t *testing.T, s []byte) string {
+	var raw []byte
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			raw = append(raw, s[i])
+			continue
+		}
+		i++
+		switch {
+		case s[i] >= '0' && s[i] <= '7':
+			end := i
+			for end < len(s) && end < i+3 && s[end] >= '0' && s[end] <= '7' {
+				end++
+			}
+			b, _ := strconv.ParseUint(string(s[i:end]), 8, 8)
+			raw = append(raw, byte(b))
+			i = end - 1
+		case s[i] == 'n':
+			raw = append(raw, '\n')
+		case s[i] == 'r':
+			raw = append(raw, '\r')
+		case s[i] == 't':
+			raw = append(raw, '\t')
+		default:
+			raw = append(raw, s[i])
+		}
+	}
+	decoded, err := charmap.Windows1252.NewDecoder().Bytes(raw)
+	assert.NoError(t, err)
+	return string(decoded)
+}
+
+func Test_report(t *testing.T) {
+	for name, test := range map[string]struct {
+		shards string
+		locale language.Tag
+	}{
+		"daily":    {catShards, language.English},
+		"mixed":    {catShardsMixed, language.English},
+		"mixed.de": {catShardsMixed, language.German},
+	} {
+		t.Run(name, func(t *testing.T) {
+			recommendation, nodes := getTestCluster(t, test.shards, "test")
+			var buf bytes.Buffer
+			assert.NoError(t, PDFRenderer{Locale: test.locale, Now: testNow}.Render(&buf, recommendation, nodes))
+			text := extractPDFText(t, buf.Bytes())
+
+			golden := filepath.Join("testdata", name+".golden")
+			if *update {
+				assert.NoError(t, os.WriteFile(golden, []byte(text), 0644))
+			}
+			expected, err := os.ReadFile(golden)
+			assert.NoError(t, err)
+			assert.Equal(t, string(expected), text)
+		})
+	}
+}
+
+func Test_getNodeNamesBySize(t *testing.T) {
+	nodes := map[string]*models.NodeStats{
+		"b": {NodeName: "b", PrimarySizeBytes: 10},
+		"a": {NodeName: "a", ReplicaSizeBytes: 10},
+		"c": {NodeName: "c", PrimarySizeBytes: 20, ReplicaSizeBytes: 5},
+	}
+	assert.Equal(t, []string{"c", "a", "b"}, getNodeNamesBySize(nodes))
+}
+
+func Test_reportPatterns(t *testing.T) {
+	r := getTestReport(t, catShardsMixed, language.English)
+	// every pattern that needs changes has its own section, not only the last one
+	var patterns []string
+	for _, pattern := range r.Patterns {
+		patterns = append(patterns, pattern.Pattern)
+	}
+	assert.Contains(t, patterns, "logs-****.**.**")
+	assert.Greater(t, len(patterns), 1)
+	assert.Equal(t, "node-1", r.Nodes.Rows[0][0])
+}
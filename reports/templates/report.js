@@ -0,0 +1,34 @@
+// sorts the rows of a table when its header is clicked, numeric cells by the raw number in their
+// data-value as the text is localized, like "2,0 GB" or "1.000.000"
+(function () {
+  function value(cell) {
+    var raw = cell.getAttribute("data-value");
+    if (raw !== null) {
+      return parseFloat(raw);
+    }
+    return cell.textContent.trim().toLowerCase();
+  }
+  function compare(a, b) {
+    if (typeof a === typeof b) {
+      return a < b ? -1 : a > b ? 1 : 0;
+    }
+    return typeof a === "number" ? -1 : 1;
+  }
+  document.querySelectorAll("table.sortable").forEach(function (table) {
+    var headers = table.querySelectorAll("thead th");
+    headers.forEach(function (th, column) {
+      th.addEventListener("click", function () {
+        var ascending = th.getAttribute("aria-sort") !== "ascending";
+        headers.forEach(function (other) { other.removeAttribute("aria-sort"); });
+        th.setAttribute("aria-sort", ascending ? "ascending" : "descending");
+        var body = table.tBodies[0];
+        Array.prototype.slice.call(body.rows)
+          .sort(function (a, b) {
+            var result = compare(value(a.cells[column]), value(b.cells[column]));
+            return ascending ? result : -result;
+          })
+          .forEach(function (row) { body.appendChild(row); });
+      });
+    });
+  });
+})();
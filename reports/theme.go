@@ -0,0 +1,188 @@
+package reports
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/johnfercher/maroto/pkg/color"
+	"github.com/johnfercher/maroto/pkg/consts"
+	"gopkg.in/yaml.v3"
+)
+
+// Theme brands the PDF report. Empty fields keep the values of DefaultTheme.
+type Theme struct {
+	Title    string      `json:"title,omitempty" yaml:"title,omitempty"`       // {cluster} is replaced by the cluster name, the translated title when empty
+	Footer   string      `json:"footer,omitempty" yaml:"footer,omitempty"`     // left of the page number on every page
+	Logo     string      `json:"logo,omitempty" yaml:"logo,omitempty"`         // base64 PNG or JPEG next to the title
+	LogoFile string      `json:"logofile,omitempty" yaml:"logofile,omitempty"` // read into Logo by LoadTheme, relative to the theme file
+	PageSize string      `json:"pagesize,omitempty" yaml:"pagesize,omitempty"` // A3, A4, A5, Letter or Legal
+	Colors   ThemeColors `json:"colors,omitempty" yaml:"colors,omitempty"`
+}
+
+// ThemeColors are hex colors like #0a0a96.
+type ThemeColors struct {
+	Primary string `json:"primary,omitempty" yaml:"primary,omitempty"` // footer and the primary shards of the charts
+	Accent  string `json:"accent,omitempty" yaml:"accent,omitempty"`   // section headers and the replicas of the charts
+	Stripe  string `json:"stripe,omitempty" yaml:"stripe,omitempty"`   // every other row of the tables
+}
+
+var pageSizes = map[string]consts.PageSize{"A3": consts.A3, "A4": consts.A4, "A5": consts.A5, "LETTER": consts.Letter, "LEGAL": consts.Legal}
+
+// DefaultTheme is the look of the report without a theme.
+func DefaultTheme() Theme {
+	return Theme{
+		Footer:   "aws.amazon.com/opensearch-service",
+		PageSize: "A4",
+		Colors: ThemeColors{
+			Primary: getHexColor(blue),
+			Accent:  getHexColor(pacificSky),
+			Stripe:  getHexColor(sanFranciscoFog),
+		},
+	}
+}
+
+// LoadTheme reads a JSON or YAML theme file over DefaultTheme, an empty path returns DefaultTheme.
+func LoadTheme(path string) (Theme, error) {
+	if path == "" {
+		return DefaultTheme(), nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Theme{}, err
+	}
+	var theme Theme
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &theme)
+	default:
+		err = json.Unmarshal(data, &theme)
+	}
+	if err != nil {
+		return Theme{}, fmt.Errorf("theme %s: %w", path, err)
+	}
+	if theme.LogoFile != "" {
+		logoFile := theme.LogoFile
+		if !filepath.IsAbs(logoFile) {
+			logoFile = filepath.Join(filepath.Dir(path), logoFile)
+		}
+		logo, err := os.ReadFile(logoFile)
+		if err != nil {
+			return Theme{}, fmt.Errorf("theme %s: %w", path, err)
+		}
+		theme.Logo = base64.StdEncoding.EncodeToString(logo)
+	}
+	theme = DefaultTheme().Merge(theme)
+	if err = theme.Validate(); err != nil {
+		return Theme{}, fmt.Errorf("theme %s: %w", path, err)
+	}
+	return theme, nil
+}
+
+// Merge returns the theme with the set fields of override. LogoFile is left out, so themes of
+// requests can't read files of the server.
+func (t Theme) Merge(override Theme) Theme {
+	for _, field := range []struct {
+		value    *string
+		override string
+	}{
+		{&t.Title, override.Title},
+		{&t.Footer, override.Footer},
+		{&t.Logo, override.Logo},
+		{&t.PageSize, override.PageSize},
+		{&t.Colors.Primary, override.Colors.Primary},
+		{&t.Colors.Accent, override.Colors.Accent},
+		{&t.Colors.Stripe, override.Colors.Stripe},
+	} {
+		if field.override != "" {
+			*field.value = field.override
+		}
+	}
+	return t
+}
+
+// Validate tells which field of the theme the PDF renderer can't apply.
+func (t Theme) Validate() error {
+	if _, ok := pageSizes[strings.ToUpper(t.PageSize)]; !ok {
+		return errors.New("pagesize must be one of A3, A4, A5, Letter or Legal")
+	}
+	for _, field := range [][2]string{{"primary", t.Colors.Primary}, {"accent", t.Colors.Accent}, {"stripe", t.Colors.Stripe}} {
+		if _, err := parseHexColor(field[1]); err != nil {
+			return fmt.Errorf("colors.%s: %w", field[0], err)
+		}
+	}
+	if t.Logo != "" {
+		if _, _, err := t.getLogo(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t Theme) getTitle(clusterName string) string {
+	return strings.ReplaceAll(t.Title, "{cluster}", clusterName)
+}
+
+func (t Theme) getPageSize() consts.PageSize {
+	if pageSize, ok := pageSizes[strings.ToUpper(t.PageSize)]; ok {
+		return pageSize
+	}
+	return consts.A4
+}
+
+// getColors returns the colors of the theme, invalid ones fall back to the defaults
+func (t Theme) getColors() pdfColors {
+	c := pdfColors{blue, pacificSky, sanFranciscoFog}
+	for _, field := range []struct {
+		value *color.Color
+		hex   string
+	}{{&c.primary, t.Colors.Primary}, {&c.accent, t.Colors.Accent}, {&c.stripe, t.Colors.Stripe}} {
+		if parsed, err := parseHexColor(field.hex); err == nil {
+			*field.value = parsed
+		}
+	}
+	return c
+}
+
+// getLogo returns the logo without whitespace and the image type maroto needs
+func (t Theme) getLogo() (string, consts.Extension, error) {
+	logo := strings.Join(strings.Fields(t.Logo), "")
+	data, err := base64.StdEncoding.DecodeString(logo)
+	if err != nil {
+		return "", "", errors.New("logo must be a base64 encoded PNG or JPEG")
+	}
+	switch http.DetectContentType(data) {
+	case "image/png":
+		return logo, consts.Png, nil
+	case "image/jpeg":
+		return logo, consts.Jpg, nil
+	}
+	return "", "", errors.New("logo must be a PNG or JPEG image")
+}
+
+// pdfColors are the colors of a theme as maroto takes them
+type pdfColors struct {
+	primary, accent, stripe color.Color
+}
+
+func parseHexColor(hex string) (color.Color, error) {
+	value := strings.TrimPrefix(hex, "#")
+	if len(value) != 6 {
+		return color.Color{}, fmt.Errorf("%q is not a color like #0a0a96", hex)
+	}
+	rgb, err := strconv.ParseUint(value, 16, 32)
+	if err != nil {
+		return color.Color{}, fmt.Errorf("%q is not a color like #0a0a96", hex)
+	}
+	return color.Color{Red: int(rgb >> 16), Green: int(rgb >> 8 & 0xff), Blue: int(rgb & 0xff)}, nil
+}
+
+func getHexColor(c color.Color) string {
+	return fmt.Sprintf("#%02x%02x%02x", c.Red, c.Green, c.Blue)
+}
@@ -0,0 +1,91 @@
+package reports
+
+import (
+	"bytes"
+	"encoding/base64"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/johnfercher/maroto/pkg/color"
+	"github.com/johnfercher/maroto/pkg/consts"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_defaultTheme(t *testing.T) {
+	theme := DefaultTheme()
+	assert.NoError(t, theme.Validate())
+	// the default is the look of the report before themes
+	assert.Equal(t, pdfColors{blue, pacificSky, sanFranciscoFog}, theme.getColors())
+	assert.Equal(t, defaultPalette, theme.getColors().getChartPalette())
+	// the title of the locale
+	assert.Empty(t, theme.Title)
+	assert.Equal(t, consts.A4, theme.getPageSize())
+}
+
+func Test_loadTheme(t *testing.T) {
+	dir := t.TempDir()
+	var logo bytes.Buffer
+	assert.NoError(t, png.Encode(&logo, image.NewRGBA(image.Rect(0, 0, 2, 2))))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "logo.png"), logo.Bytes(), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "theme.yaml"), []byte(`title: Shard review of {cluster}
+footer: example.com
+logofile: logo.png
+pagesize: letter
+colors:
+  primary: "#c00000"
+`), 0644))
+
+	theme, err := LoadTheme(filepath.Join(dir, "theme.yaml"))
+	assert.NoError(t, err)
+	assert.Equal(t, "Shard review of prod", theme.getTitle("prod"))
+	assert.Equal(t, "example.com", theme.Footer)
+	assert.Equal(t, consts.Letter, theme.getPageSize())
+	assert.Equal(t, color.Color{Red: 192}, theme.getColors().primary)
+	// unset fields keep the defaults
+	assert.Equal(t, pacificSky, theme.getColors().accent)
+	encoded, extension, err := theme.getLogo()
+	assert.NoError(t, err)
+	assert.Equal(t, base64.StdEncoding.EncodeToString(logo.Bytes()), encoded)
+	assert.Equal(t, consts.Png, extension)
+
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "theme.json"), []byte(`{"pagesize": "B5"}`), 0644))
+	_, err = LoadTheme(filepath.Join(dir, "theme.json"))
+	assert.ErrorContains(t, err, "pagesize must be one of")
+}
+
+func Test_mergeTheme(t *testing.T) {
+	theme := DefaultTheme().Merge(Theme{Footer: "example.com", LogoFile: "/etc/passwd", Colors: ThemeColors{Stripe: "#ffffff"}})
+	assert.Equal(t, "example.com", theme.Footer)
+	assert.Empty(t, theme.Title)
+	assert.Equal(t, "#ffffff", theme.Colors.Stripe)
+	// themes of requests don't read files
+	assert.Empty(t, theme.LogoFile)
+
+	assert.ErrorContains(t, DefaultTheme().Merge(Theme{Colors: ThemeColors{Accent: "blue"}}).Validate(), "colors.accent")
+	assert.ErrorContains(t, DefaultTheme().Merge(Theme{Logo: base64.StdEncoding.EncodeToString([]byte("not an image"))}).Validate(), "PNG or JPEG")
+}
+
+func Test_pdfRendererMergesTheTheme(t *testing.T) {
+	recommendation, nodes := getTestCluster(t, catShards, "test")
+	var buf bytes.Buffer
+	assert.NoError(t, PDFRenderer{Theme: Theme{Title: "Shards of {cluster}"}, Now: testNow}.Render(&buf, recommendation, nodes))
+	text := extractPDFText(t, buf.Bytes())
+	assert.Contains(t, text, "Shards of "+recommendation.ClusterName)
+	// the footer of the default theme is kept
+	assert.Contains(t, text, DefaultTheme().Footer)
+
+	logo := Theme{Logo: base64.StdEncoding.EncodeToString([]byte("not an image"))}
+	err := PDFRenderer{Theme: logo, Now: testNow}.Render(&buf, recommendation, nodes)
+	assert.ErrorContains(t, err, "PNG or JPEG")
+}
+
+func Test_getChartPalette(t *testing.T) {
+	palette := pdfColors{color.Color{}, color.NewWhite(), sanFranciscoFog}.getChartPalette()
+	assert.Len(t, palette.shades, len(defaultPalette.shades))
+	// the shades blend the primary color into the accent
+	assert.Equal(t, palette.primary, palette.shades[0])
+	assert.Equal(t, palette.accent, palette.shades[len(palette.shades)-1])
+}
@@ -1,7 +1,6 @@
 package reports
 
 import (
-	"fmt"
 	"github.com/johnfercher/maroto/pkg/color"
 	"shardanalyzer/models"
 )
@@ -27,17 +26,7 @@ var (
 )
 
 func ByteCountIEC(b int64) string {
-	const unit = 1024
-	if b < unit {
-		return fmt.Sprintf("%d B", b)
-	}
-	div, exp := int64(unit), 0
-	for n := b / unit; n >= unit; n /= unit {
-		div *= unit
-		exp++
-	}
-	return fmt.Sprintf("%.1f %cB",
-		float64(b)/float64(div), "KMGTPE"[exp])
+	return models.ByteCountIEC(b)
 }
 
 func MergeSingleIndexPatterns(recommendation *models.Recommendation) {
@@ -52,7 +41,7 @@ func MergeSingleIndexPatterns(recommendation *models.Recommendation) {
 		Indices:     []*models.IndexRecommendation{},
 	}
 	for _, ipr := range recommendation.IndexPatternRecommendationRollup {
-		if len(ipr.Indices) == 1 {
+		if len(ipr.Indices) == 1 && !ipr.IsDataStream() {
 			if ipr.NeedChanges {
 				indRIndices.Indices = append(indRIndices.Indices, ipr.Indices...)
 			} else {
@@ -0,0 +1,157 @@
+package reports
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// writeXLSX writes the sheets as a minimal Office Open XML workbook: inline strings, a bold and
+// frozen header row and a filter on every sheet.
+func writeXLSX(w io.Writer, sheets []sheet) error {
+	archive := zip.NewWriter(w)
+	files := []xlsxFile{
+		{"[Content_Types].xml", getXLSXContentTypes(len(sheets))},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", getXLSXWorkbook(sheets)},
+		{"xl/_rels/workbook.xml.rels", getXLSXWorkbookRels(len(sheets))},
+		{"xl/styles.xml", xlsxStyles},
+	}
+	for i, s := range sheets {
+		files = append(files, xlsxFile{fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), getXLSXSheet(s)})
+	}
+	for _, file := range files {
+		f, err := archive.Create(file.name)
+		if err != nil {
+			return err
+		}
+		if _, err = io.WriteString(f, file.content); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+type xlsxFile struct {
+	name    string
+	content string
+}
+
+const xlsxHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n"
+
+const xlsxRootRels = xlsxHeader + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+// style 1 is the bold font of the header rows
+const xlsxStyles = xlsxHeader + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/><xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>` +
+	`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>` +
+	`</styleSheet>`
+
+func getXLSXContentTypes(sheetCount int) string {
+	var buf bytes.Buffer
+	buf.WriteString(xlsxHeader + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	buf.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	buf.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	buf.WriteString(`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	buf.WriteString(`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	for i := 1; i <= sheetCount; i++ {
+		fmt.Fprintf(&buf, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i)
+	}
+	buf.WriteString(`</Types>`)
+	return buf.String()
+}
+
+func getXLSXWorkbook(sheets []sheet) string {
+	var buf bytes.Buffer
+	buf.WriteString(xlsxHeader + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	for i, s := range sheets {
+		fmt.Fprintf(&buf, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, escapeXML(s.Name), i+1, i+1)
+	}
+	buf.WriteString(`</sheets><definedNames>`)
+	// Excel keeps the range of a filter in a hidden name
+	for i, s := range sheets {
+		fmt.Fprintf(&buf, `<definedName name="_xlnm._FilterDatabase" localSheetId="%d" hidden="1">'%s'!%s</definedName>`, i, escapeXML(s.Name), getXLSXRange(s, true))
+	}
+	buf.WriteString(`</definedNames></workbook>`)
+	return buf.String()
+}
+
+func getXLSXWorkbookRels(sheetCount int) string {
+	var buf bytes.Buffer
+	buf.WriteString(xlsxHeader + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i := 1; i <= sheetCount; i++ {
+		fmt.Fprintf(&buf, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i, i)
+	}
+	fmt.Fprintf(&buf, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, sheetCount+1)
+	buf.WriteString(`</Relationships>`)
+	return buf.String()
+}
+
+func getXLSXSheet(s sheet) string {
+	var buf bytes.Buffer
+	buf.WriteString(xlsxHeader + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	buf.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+	buf.WriteString(`<sheetData><row r="1">`)
+	for column, name := range s.Header {
+		fmt.Fprintf(&buf, `<c r="%s1" t="inlineStr" s="1"><is><t>%s</t></is></c>`, getXLSXColumn(column), escapeXML(name))
+	}
+	buf.WriteString(`</row>`)
+	for i, row := range s.Rows {
+		r := strconv.Itoa(i + 2)
+		buf.WriteString(`<row r="` + r + `">`)
+		for column, cell := range row {
+			ref := getXLSXColumn(column) + r
+			switch value := cell.(type) {
+			case string:
+				fmt.Fprintf(&buf, `<c r="%s" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, ref, escapeXML(value))
+			case bool:
+				b := 0
+				if value {
+					b = 1
+				}
+				fmt.Fprintf(&buf, `<c r="%s" t="b"><v>%d</v></c>`, ref, b)
+			default:
+				fmt.Fprintf(&buf, `<c r="%s"><v>%s</v></c>`, ref, formatCell(value))
+			}
+		}
+		buf.WriteString(`</row>`)
+	}
+	buf.WriteString(`</sheetData>`)
+	fmt.Fprintf(&buf, `<autoFilter ref="%s"/>`, getXLSXRange(s, false))
+	buf.WriteString(`</worksheet>`)
+	return buf.String()
+}
+
+// getXLSXRange returns the range of the header and the rows, like A1:J42 or $A$1:$J$42
+func getXLSXRange(s sheet, absolute bool) string {
+	last := getXLSXColumn(len(s.Header)-1) + "$" + strconv.Itoa(len(s.Rows)+1)
+	if !absolute {
+		return "A1:" + strings.Replace(last, "$", "", 1)
+	}
+	return "$A$1:$" + last
+}
+
+// getXLSXColumn returns the letters of a zero based column, A to Z then AA
+func getXLSXColumn(column int) string {
+	name := ""
+	for column++; column > 0; column = (column - 1) / 26 {
+		name = string(rune('A'+(column-1)%26)) + name
+	}
+	return name
+}
+
+func escapeXML(text string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(text))
+	return buf.String()
+}
@@ -0,0 +1,89 @@
+package secrets
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+const Redacted = "[REDACTED]"
+
+// sensitive JSON fields of request bodies and headers of requests, all lower case
+var (
+	sensitiveFields  = []string{"password", "secret", "token", "cacert"}
+	sensitiveHeaders = []string{"authorization", "x-amz-security-token", "x-api-key", "cookie"}
+)
+
+func isSensitive(name string, names []string) bool {
+	name = strings.ToLower(name)
+	for _, sensitive := range names {
+		if strings.Contains(name, sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactJSON replaces the values of sensitive fields at any depth of a JSON body. Bodies that
+// are not JSON can't be checked, so they are replaced as a whole.
+func RedactJSON(body string) string {
+	if strings.TrimSpace(body) == "" {
+		return body
+	}
+	var value interface{}
+	if err := json.Unmarshal([]byte(body), &value); err != nil {
+		return Redacted
+	}
+	redacted, err := json.Marshal(redactValue(value))
+	if err != nil {
+		return Redacted
+	}
+	return string(redacted)
+}
+
+func redactValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if isSensitive(key, sensitiveFields) {
+				v[key] = Redacted
+			} else {
+				v[key] = redactValue(field)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactValue(item)
+		}
+	}
+	return value
+}
+
+// RedactHeaders returns a copy of the headers with the credentials replaced.
+func RedactHeaders(headers map[string]string) map[string]string {
+	if headers == nil {
+		return nil
+	}
+	redacted := make(map[string]string, len(headers))
+	for name, value := range headers {
+		if isSensitive(name, sensitiveHeaders) {
+			value = Redacted
+		}
+		redacted[name] = value
+	}
+	return redacted
+}
+
+// RedactMultiValueHeaders is RedactHeaders for headers with several values.
+func RedactMultiValueHeaders(headers map[string][]string) map[string][]string {
+	if headers == nil {
+		return nil
+	}
+	redacted := make(map[string][]string, len(headers))
+	for name, values := range headers {
+		if isSensitive(name, sensitiveHeaders) {
+			values = []string{Redacted}
+		}
+		redacted[name] = values
+	}
+	return redacted
+}
@@ -0,0 +1,113 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+// Credentials are the basic auth credentials of a domain.
+type Credentials struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// Resolver looks up the credentials a reference points to.
+type Resolver interface {
+	Resolve(ctx context.Context, ref string) (Credentials, error)
+}
+
+type secretsManagerAPI interface {
+	GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error)
+}
+
+type ssmAPI interface {
+	GetParameter(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error)
+}
+
+// AWSResolver reads credentials from Secrets Manager or from SSM Parameter Store. Both hold the
+// JSON {"username": "...", "password": "..."}, parameters as SecureString.
+type AWSResolver struct {
+	secretsManager secretsManagerAPI
+	ssm            ssmAPI
+}
+
+// NewDefaultAWSResolver uses the default credential chain of the Lambda.
+func NewDefaultAWSResolver(ctx context.Context) (*AWSResolver, error) {
+	cfg, err := config.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("loading AWS config: %w", err)
+	}
+	return NewAWSResolver(cfg), nil
+}
+
+func NewAWSResolver(cfg aws.Config) *AWSResolver {
+	return &AWSResolver{
+		secretsManager: secretsmanager.NewFromConfig(cfg),
+		ssm:            ssm.NewFromConfig(cfg),
+	}
+}
+
+// Resolve takes a secret ARN, a parameter ARN or a parameter name starting with /.
+func (r *AWSResolver) Resolve(ctx context.Context, ref string) (Credentials, error) {
+	var value string
+	switch {
+	case strings.HasPrefix(ref, "arn:aws:secretsmanager:"):
+		out, err := r.secretsManager.GetSecretValue(ctx, &secretsmanager.GetSecretValueInput{SecretId: aws.String(ref)}, func(o *secretsmanager.Options) {
+			o.Region = getRegion(ref, o.Region)
+		})
+		if err != nil {
+			return Credentials{}, fmt.Errorf("reading secret %s: %w", ref, err)
+		}
+		value = aws.ToString(out.SecretString)
+	case strings.HasPrefix(ref, "arn:aws:ssm:") || strings.HasPrefix(ref, "/"):
+		out, err := r.ssm.GetParameter(ctx, &ssm.GetParameterInput{Name: aws.String(ref), WithDecryption: aws.Bool(true)}, func(o *ssm.Options) {
+			o.Region = getRegion(ref, o.Region)
+		})
+		if err != nil {
+			return Credentials{}, fmt.Errorf("reading parameter %s: %w", ref, err)
+		}
+		value = aws.ToString(out.Parameter.Value)
+	default:
+		return Credentials{}, fmt.Errorf("%s is neither a Secrets Manager ARN nor an SSM parameter", ref)
+	}
+	return parseCredentials(ref, value)
+}
+
+// getRegion reads the region of an ARN, secrets may live in another region than the Lambda.
+func getRegion(ref string, region string) string {
+	parts := strings.Split(ref, ":")
+	if len(parts) > 3 && parts[0] == "arn" && parts[3] != "" {
+		return parts[3]
+	}
+	return region
+}
+
+func parseCredentials(ref string, value string) (creds Credentials, err error) {
+	// the value is left out of the errors, it is the secret
+	if err = json.Unmarshal([]byte(value), &creds); err != nil {
+		return Credentials{}, fmt.Errorf("%s does not hold a JSON object with username and password", ref)
+	}
+	if creds.Username == "" || creds.Password == "" {
+		return Credentials{}, errors.New(ref + " has an empty username or password")
+	}
+	return
+}
+
+// StaticResolver resolves references from a map, for tests and local runs.
+type StaticResolver map[string]Credentials
+
+func (r StaticResolver) Resolve(ctx context.Context, ref string) (Credentials, error) {
+	creds, ok := r[ref]
+	if !ok {
+		return Credentials{}, fmt.Errorf("no credentials for %s", ref)
+	}
+	return creds, nil
+}
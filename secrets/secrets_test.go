@@ -0,0 +1,94 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeStore serves both APIs from one map and records the region of the last call.
+type fakeStore struct {
+	values map[string]string
+	region string
+}
+
+func (f *fakeStore) GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error) {
+	options := secretsmanager.Options{Region: "us-east-1"}
+	for _, fn := range optFns {
+		fn(&options)
+	}
+	f.region = options.Region
+	value, ok := f.values[aws.ToString(params.SecretId)]
+	if !ok {
+		return nil, errors.New("ResourceNotFoundException")
+	}
+	return &secretsmanager.GetSecretValueOutput{SecretString: aws.String(value)}, nil
+}
+
+func (f *fakeStore) GetParameter(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error) {
+	options := ssm.Options{Region: "us-east-1"}
+	for _, fn := range optFns {
+		fn(&options)
+	}
+	f.region = options.Region
+	value, ok := f.values[aws.ToString(params.Name)]
+	if !ok || !aws.ToBool(params.WithDecryption) {
+		return nil, errors.New("ParameterNotFound")
+	}
+	return &ssm.GetParameterOutput{Parameter: &ssmtypes.Parameter{Value: aws.String(value)}}, nil
+}
+
+func Test_awsResolver(t *testing.T) {
+	secretArn := "arn:aws:secretsmanager:eu-west-1:123456789012:secret:domain-admin"
+	store := &fakeStore{values: map[string]string{
+		secretArn:      `{"username": "admin", "password": "s3cret"}`,
+		"/domain/user": `{"username": "reader", "password": "p4ss"}`,
+		"/domain/bad":  `admin:s3cret`,
+	}}
+	resolver := &AWSResolver{secretsManager: store, ssm: store}
+
+	creds, err := resolver.Resolve(context.Background(), secretArn)
+	assert.NoError(t, err)
+	assert.Equal(t, Credentials{Username: "admin", Password: "s3cret"}, creds)
+	assert.Equal(t, "eu-west-1", store.region)
+
+	creds, err = resolver.Resolve(context.Background(), "/domain/user")
+	assert.NoError(t, err)
+	assert.Equal(t, "reader", creds.Username)
+	assert.Equal(t, "us-east-1", store.region)
+
+	_, err = resolver.Resolve(context.Background(), "/domain/bad")
+	assert.Error(t, err)
+	assert.NotContains(t, err.Error(), "s3cret")
+	_, err = resolver.Resolve(context.Background(), "/domain/missing")
+	assert.Error(t, err)
+	_, err = resolver.Resolve(context.Background(), "domain-admin")
+	assert.ErrorContains(t, err, "neither")
+
+	static := StaticResolver{"ref": creds}
+	creds, err = static.Resolve(context.Background(), "ref")
+	assert.NoError(t, err)
+	assert.Equal(t, "reader", creds.Username)
+}
+
+func Test_redact(t *testing.T) {
+	body := `{"domainendpoint": "https://search", "username": "admin", "password": "s3cret", "nested": [{"SessionToken": "t"}]}`
+	redacted := RedactJSON(body)
+	assert.NotContains(t, redacted, "s3cret")
+	assert.NotContains(t, redacted, `"t"`)
+	assert.Contains(t, redacted, `"username":"admin"`)
+	assert.Equal(t, Redacted, RedactJSON(`password=s3cret`))
+	assert.Equal(t, "", RedactJSON(""))
+
+	headers := RedactHeaders(map[string]string{"Authorization": "Basic YWRtaW46czNjcmV0", "Accept": "application/json"})
+	assert.Equal(t, Redacted, headers["Authorization"])
+	assert.Equal(t, "application/json", headers["Accept"])
+	multi := RedactMultiValueHeaders(map[string][]string{"X-Amz-Security-Token": {"a", "b"}})
+	assert.Equal(t, []string{Redacted}, multi["X-Amz-Security-Token"])
+}